@@ -0,0 +1,42 @@
+package vayuotel
+
+import (
+	"io"
+	"log"
+	"time"
+
+	"github.com/kaushiksamanta/vayu"
+)
+
+// TracedLogger returns an access log middleware like Vayu's Logger whose lines also carry the
+// request's trace_id and span_id, so logs can be joined with traces. It writes to w, or to the
+// standard logger if w is nil. It can be registered before or after the tracing middleware,
+// since the span is read once the handler chain has finished
+func TracedLogger(w io.Writer) vayu.HandlerFunc {
+	logger := log.Default()
+	if w != nil {
+		logger = log.New(w, "", log.LstdFlags)
+	}
+
+	return func(c *vayu.Context, next vayu.NextFunc) {
+		start := time.Now()
+		recorder := NewTracingResponseWriter(c.Writer)
+		c.Writer = recorder
+
+		next()
+
+		// Prefer the handler duration measured by the tracing middleware
+		duration, ok := RequestDuration(c)
+		if !ok {
+			duration = time.Since(start)
+		}
+
+		traceID, spanID := GetTraceID(c), GetSpanID(c)
+		if traceID == "" {
+			traceID, spanID = "-", "-"
+		}
+
+		logger.Printf("%s %s %d %s trace_id=%s span_id=%s",
+			c.Request.Method, c.Request.URL.Path, recorder.StatusCode(), duration, traceID, spanID)
+	}
+}
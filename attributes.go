@@ -1,6 +1,7 @@
 package vayuotel
 
 import (
+	"context"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -39,11 +40,43 @@ func TimestampAttribute(key string, value time.Time) attribute.KeyValue {
 	return attribute.Int64(key, value.UnixNano())
 }
 
+// StringSliceAttribute creates a string slice attribute
+func StringSliceAttribute(key string, value []string) attribute.KeyValue {
+	return attribute.StringSlice(key, value)
+}
+
+// IntSliceAttribute creates an int slice attribute
+func IntSliceAttribute(key string, value []int) attribute.KeyValue {
+	return attribute.IntSlice(key, value)
+}
+
+// Float64SliceAttribute creates a float64 slice attribute
+func Float64SliceAttribute(key string, value []float64) attribute.KeyValue {
+	return attribute.Float64Slice(key, value)
+}
+
+// BoolSliceAttribute creates a bool slice attribute
+func BoolSliceAttribute(key string, value []bool) attribute.KeyValue {
+	return attribute.BoolSlice(key, value)
+}
+
 // SpanOption is an interface for applying options to a span
 type SpanOption interface {
 	Apply(span trace.Span)
 }
 
+// spanStartOption is implemented by span options that must be applied when the span starts
+// (e.g. links), since they cannot be added to a span afterwards
+type spanStartOption interface {
+	startOptions() []trace.SpanStartOption
+}
+
+// spanParentOption is implemented by span options that adjust the parent context before the
+// span starts (e.g. trace state, which the new span inherits from its parent)
+type spanParentOption interface {
+	parentContext(ctx context.Context) context.Context
+}
+
 // WithAttributes returns a SpanOption that sets attributes on a span
 type WithAttributes []attribute.KeyValue
 
@@ -93,7 +126,88 @@ func WithTimestampAttribute(key string, value time.Time) SpanOption {
 	return WithAttributes{TimestampAttribute(key, value)}
 }
 
+// WithStringSliceAttribute creates a span option with a string slice attribute
+func WithStringSliceAttribute(key string, value []string) SpanOption {
+	return WithAttributes{StringSliceAttribute(key, value)}
+}
+
+// WithIntSliceAttribute creates a span option with an int slice attribute
+func WithIntSliceAttribute(key string, value []int) SpanOption {
+	return WithAttributes{IntSliceAttribute(key, value)}
+}
+
+// WithFloat64SliceAttribute creates a span option with a float64 slice attribute
+func WithFloat64SliceAttribute(key string, value []float64) SpanOption {
+	return WithAttributes{Float64SliceAttribute(key, value)}
+}
+
+// WithBoolSliceAttribute creates a span option with a bool slice attribute
+func WithBoolSliceAttribute(key string, value []bool) SpanOption {
+	return WithAttributes{BoolSliceAttribute(key, value)}
+}
+
 // WithEventName creates a span option that adds an event with the given name
 func WithEventName(name string) SpanOption {
 	return WithEvent{Name: name}
 }
+
+// linkOption is a SpanOption that links the new span to another span context
+type linkOption struct {
+	link trace.Link
+}
+
+// Apply implements SpanOption; links are added when the span starts
+func (l linkOption) Apply(span trace.Span) {}
+
+// startOptions implements spanStartOption
+func (l linkOption) startOptions() []trace.SpanStartOption {
+	return []trace.SpanStartOption{trace.WithLinks(l.link)}
+}
+
+// WithLink creates a span option that links the new span to spanCtx with the given attributes
+// Multiple WithLink options accumulate
+func WithLink(spanCtx trace.SpanContext, attributes map[string]interface{}) SpanOption {
+	return linkOption{
+		link: trace.Link{
+			SpanContext: spanCtx,
+			Attributes:  convertToAttributes(attributes),
+		},
+	}
+}
+
+// newRootOption is a SpanOption that starts a new trace instead of continuing the parent's
+type newRootOption struct{}
+
+// Apply implements SpanOption; the span is made a root when it starts
+func (newRootOption) Apply(span trace.Span) {}
+
+// startOptions implements spanStartOption
+func (newRootOption) startOptions() []trace.SpanStartOption {
+	return []trace.SpanStartOption{trace.WithNewRoot()}
+}
+
+// WithNewRoot creates a span option that starts a new trace, ignoring the parent span in the
+// context (e.g. for a job triggered by a request that should not inherit its trace)
+func WithNewRoot() SpanOption {
+	return newRootOption{}
+}
+
+// traceStateOption is a SpanOption that sets the W3C trace state of the new span
+type traceStateOption struct {
+	state trace.TraceState
+}
+
+// Apply implements SpanOption; the trace state is set when the span starts
+func (t traceStateOption) Apply(span trace.Span) {}
+
+// parentContext implements spanParentOption
+func (t traceStateOption) parentContext(ctx context.Context) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	return trace.ContextWithSpanContext(ctx, sc.WithTraceState(t.state))
+}
+
+// WithTraceState creates a span option that sets the W3C tracestate of the new span
+// (e.g. vendor sampling hints), replacing the state inherited from the parent
+func WithTraceState(state trace.TraceState) SpanOption {
+	return traceStateOption{state: state}
+}
@@ -2,23 +2,66 @@ package vayuotel
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"runtime/debug"
+	"strings"
 	"time"
 
 	"maps"
+	"slices"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// OTLP transport protocols supported by the exporter
+const (
+	// OTLPProtocolGRPC exports spans using OTLP over gRPC
+	OTLPProtocolGRPC = "grpc"
+)
+
+// Compression algorithms supported for OTLP export
+const (
+	// CompressionNone sends uncompressed payloads
+	CompressionNone = "none"
+
+	// CompressionGzip gzip-compresses payloads to save bandwidth
+	CompressionGzip = "gzip"
+)
+
+// Span export modes
+const (
+	// ExportModeBatch queues spans and exports them in batches in the background
+	ExportModeBatch = "batch"
+
+	// ExportModeSimple exports each span synchronously when it ends, so short-lived
+	// CLI and serverless invocations do not depend on a final flush
+	ExportModeSimple = "simple"
+)
+
+// Metrics exporters supported by the provider
+const (
+	// MetricsExporterPrometheus exposes metrics for scraping via Integration.PrometheusHandler
+	MetricsExporterPrometheus = "prometheus"
+)
+
 // Config holds configuration for OpenTelemetry integration
 type Config struct {
 	// ServiceName is the name of the service (required)
@@ -30,26 +73,145 @@ type Config struct {
 	// Environment is the deployment environment (e.g., "production", "staging")
 	Environment string
 
-	// OTLPEndpoint is the endpoint for the OpenTelemetry collector (e.g., "localhost:4317")
+	// Revision is the deployed source revision (e.g. a git commit), recorded as the
+	// "service.revision" resource attribute. Defaults to the VCS revision in the binary's build info
+	Revision string
+
+	// OTLPEndpoint is the endpoint for the OpenTelemetry collector (e.g., "localhost:4317").
+	// A URL such as "https://collector.example.com:4317" is also accepted: "http://" and
+	// "https://" override Insecure, and the port defaults to 4317 for bare hosts
 	OTLPEndpoint string
 
+	// OTLPProtocol is the transport protocol used to reach the collector (defaults to "grpc")
+	OTLPProtocol string
+
+	// Compression is the compression applied to OTLP payloads: "gzip" or "none" (default)
+	Compression string
+
+	// JaegerEndpoint is the Jaeger collector HTTP endpoint (e.g. "http://jaeger:14268/api/traces")
+	// for legacy deployments without OTLP; when set it is used instead of OTLPEndpoint
+	//
+	// Deprecated: Jaeger accepts OTLP natively and the OpenTelemetry Jaeger exporter is no
+	// longer maintained. Point OTLPEndpoint at the Jaeger collector instead
+	JaegerEndpoint string
+
 	// UseStdout enables printing traces to stdout (useful for development)
 	UseStdout bool
 
+	// SyncExport exports each span as soon as it ends instead of batching
+	//
+	// Deprecated: set ExportMode to "simple" instead. SyncExport cannot be combined with
+	// ExportMode "batch"
+	SyncExport bool
+
+	// ExportMode selects how spans are handed to the exporter ("batch" or "simple"); empty
+	// means batch. "simple" exports each span as soon as it ends (useful with UseStdout during
+	// development, CLI tools and serverless functions). It blocks the caller on every export,
+	// so keep batching in production
+	ExportMode string
+
+	// DisableGlobal skips registering the tracer provider, propagator and meter provider as the
+	// OpenTelemetry globals, to create isolated providers (e.g. in concurrent tests)
+	DisableGlobal bool
+
+	// Disabled installs a no-op tracer provider so tracing APIs can be called without
+	// creating an exporter or recording spans (useful for tests and local runs)
+	Disabled bool
+
 	// Insecure disables transport security for gRPC connections to the collector
 	Insecure bool
 
 	// Headers to add to the gRPC connection
 	Headers map[string]string
 
+	// VerifyConnection makes Setup check that the collector is reachable (bounded by ExportTimeout)
+	// and fail with ErrCollectorUnreachable instead of letting the first export fail silently
+	VerifyConnection bool
+
+	// GRPCConn is an existing connection to the collector for the exporter to reuse instead of dialing
+	// its own. The caller owns the connection and must close it after the provider is shut down
+	GRPCConn *grpc.ClientConn
+
+	// ExtraDialOptions are appended to the exporter's gRPC dial options (e.g. custom interceptors)
+	// They are ignored when GRPCConn is set
+	ExtraDialOptions []grpc.DialOption
+
+	// Retry controls how failed exports are retried; nil keeps the exporter's default policy.
+	// Set Enabled to false to turn retries off
+	Retry *RetryConfig
+
+	// BlockOnConnect makes NewProvider wait for the collector connection, bounded by ExportTimeout.
+	// By default the connection is established in the background so startup never hangs
+	BlockOnConnect bool
+
 	// BatchTimeout is the maximum time to wait for a batch to be exported
 	BatchTimeout time.Duration
 
 	// BatchSize is the maximum number of spans to batch before exporting
 	BatchSize int
 
+	// MaxQueueSize is the maximum number of spans buffered for export; spans are dropped once it is full
+	MaxQueueSize int
+
+	// BlockOnQueueFull makes ending a span wait for room in a full queue instead of dropping it.
+	// No spans are lost, but request latency then depends on the collector's throughput
+	BlockOnQueueFull bool
+
+	// ExportTimeout is the maximum time a single export to the collector may take
+	ExportTimeout time.Duration
+
 	// AdditionalAttributes are custom attributes to add to every span
 	AdditionalAttributes []ResourceAttribute
+
+	// MetricsExporter selects how metrics are exported (e.g. "prometheus"); empty disables metrics
+	MetricsExporter string
+
+	// Sampler decides which traces are recorded; defaults to always sampling
+	Sampler sdktrace.Sampler
+
+	// SampleRatio, when set and Sampler is nil, samples this fraction (0, 1] of root traces
+	// while respecting the sampling decision of incoming parents
+	SampleRatio float64
+
+	// SlowRequestThreshold forces spans lasting at least this long to be exported even when
+	// the sampler dropped them (zero disables). Unsampled spans are then recorded, which adds overhead
+	SlowRequestThreshold time.Duration
+
+	// Propagators extract and inject trace context in request headers, in order
+	// Defaults to W3C TraceContext and Baggage; add CloudTraceContext{} or a B3 propagator
+	// to continue traces from upstreams that do not send traceparent
+	Propagators []propagation.TextMapPropagator
+
+	// RedactAttributes lists span attribute keys whose values are exported as "[REDACTED]"
+	// (e.g. emails or tokens that must never reach the backend in cleartext)
+	RedactAttributes []string
+
+	// SpanLimits bounds the number and size of attributes recorded on each span
+	SpanLimits SpanLimits
+
+	// ResourceDetectors add attributes detected at startup (e.g. host or cloud metadata) to the resource
+	// Partial detection failures are reported to Logger and the detected attributes are still used
+	ResourceDetectors []resource.Detector
+
+	// Logger receives internal diagnostics such as partial resource detection; defaults to a no-op
+	Logger Logger
+
+	// InstrumentationName is the instrumentation scope name of spans and metrics (defaults to "vayu-http")
+	InstrumentationName string
+
+	// InstrumentationVersion is the instrumentation scope version of spans and metrics
+	InstrumentationVersion string
+
+	// Exporter overrides the exporter selected by UseStdout/OTLPEndpoint (useful for testing)
+	Exporter sdktrace.SpanExporter
+
+	// IDGenerator generates trace and span IDs (e.g. NewXRayIDGenerator for AWS X-Ray);
+	// defaults to random IDs
+	IDGenerator sdktrace.IDGenerator
+
+	// ExtraSpanProcessors are registered in addition to the exporting processor and are
+	// flushed and shut down with the tracer provider
+	ExtraSpanProcessors []sdktrace.SpanProcessor
 }
 
 // ResourceAttribute is a key-value pair to add to resource attributes
@@ -58,28 +220,254 @@ type ResourceAttribute struct {
 	Value string
 }
 
+// RetryConfig configures retries of span batches the collector failed to receive
+type RetryConfig struct {
+	// Enabled turns retries on
+	Enabled bool
+
+	// InitialInterval is the time to wait after the first failure before retrying
+	InitialInterval time.Duration
+
+	// MaxInterval is the upper bound on the backoff interval between retries
+	MaxInterval time.Duration
+
+	// MaxElapsedTime is the maximum time spent retrying a batch before it is dropped
+	MaxElapsedTime time.Duration
+}
+
+// SpanLimits bounds span attributes to keep backend costs predictable; zero values keep the SDK defaults
+type SpanLimits struct {
+	// MaxAttributeCount is the maximum number of attributes per span; extra attributes are dropped
+	MaxAttributeCount int
+
+	// MaxAttributeValueLength is the maximum length of string attribute values; longer values are truncated
+	MaxAttributeValueLength int
+}
+
+// Clone returns a copy of the config whose maps and slices are not shared with c, so
+// configs derived from the same base can be changed independently
+func (c Config) Clone() Config {
+	clone := c
+	clone.Headers = maps.Clone(c.Headers)
+	clone.AdditionalAttributes = slices.Clone(c.AdditionalAttributes)
+	clone.ExtraDialOptions = slices.Clone(c.ExtraDialOptions)
+	clone.Propagators = slices.Clone(c.Propagators)
+	clone.RedactAttributes = slices.Clone(c.RedactAttributes)
+	clone.ResourceDetectors = slices.Clone(c.ResourceDetectors)
+	clone.ExtraSpanProcessors = slices.Clone(c.ExtraSpanProcessors)
+	return clone
+}
+
 // DefaultConfig returns a default configuration
 func DefaultConfig() Config {
 	return Config{
-		ServiceName:    "vayu-service",
-		ServiceVersion: "0.1.0",
-		Environment:    "development",
-		OTLPEndpoint:   "localhost:4317",
-		UseStdout:      false,
-		Insecure:       true,
-		BatchTimeout:   5 * time.Second,
-		BatchSize:      512,
+		ServiceName:         "vayu-service",
+		ServiceVersion:      "0.1.0",
+		Environment:         "development",
+		OTLPEndpoint:        "localhost:4317",
+		OTLPProtocol:        OTLPProtocolGRPC,
+		Compression:         CompressionNone,
+		InstrumentationName: tracerNameValue,
+		UseStdout:           false,
+		Insecure:            true,
+		BatchTimeout:        5 * time.Second,
+		BatchSize:           512,
+		MaxQueueSize:        2048,
+		ExportTimeout:       30 * time.Second,
+		Retry: &RetryConfig{
+			Enabled:         true,
+			InitialInterval: 5 * time.Second,
+			MaxInterval:     30 * time.Second,
+			MaxElapsedTime:  time.Minute,
+		},
 	}
 }
 
+// Validate checks the configuration and returns an error wrapping ErrInvalidConfig if it is invalid
+func (c Config) Validate() error {
+	if c.ServiceName == "" {
+		return fmt.Errorf("%w: service name is required", ErrInvalidConfig)
+	}
+
+	if c.Exporter == nil && !c.UseStdout && c.JaegerEndpoint == "" && c.OTLPEndpoint == "" {
+		return fmt.Errorf("%w: OTLP endpoint is required when not using stdout", ErrInvalidConfig)
+	}
+
+	if c.BatchSize < 0 {
+		return fmt.Errorf("%w: batch size must not be negative, got %d", ErrInvalidConfig, c.BatchSize)
+	}
+
+	if c.BatchTimeout < 0 {
+		return fmt.Errorf("%w: batch timeout must not be negative, got %s", ErrInvalidConfig, c.BatchTimeout)
+	}
+
+	if c.MaxQueueSize < 0 {
+		return fmt.Errorf("%w: max queue size must not be negative, got %d", ErrInvalidConfig, c.MaxQueueSize)
+	}
+
+	if c.ExportTimeout < 0 {
+		return fmt.Errorf("%w: export timeout must not be negative, got %s", ErrInvalidConfig, c.ExportTimeout)
+	}
+
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("%w: sample ratio must be between 0 and 1, got %g", ErrInvalidConfig, c.SampleRatio)
+	}
+
+	if c.SlowRequestThreshold < 0 {
+		return fmt.Errorf("%w: slow request threshold must not be negative, got %s", ErrInvalidConfig, c.SlowRequestThreshold)
+	}
+
+	if c.Retry != nil && (c.Retry.InitialInterval < 0 || c.Retry.MaxInterval < 0 || c.Retry.MaxElapsedTime < 0) {
+		return fmt.Errorf("%w: retry intervals must not be negative", ErrInvalidConfig)
+	}
+
+	if c.SpanLimits.MaxAttributeCount < 0 {
+		return fmt.Errorf("%w: max attribute count must not be negative, got %d", ErrInvalidConfig, c.SpanLimits.MaxAttributeCount)
+	}
+
+	if c.SpanLimits.MaxAttributeValueLength < 0 {
+		return fmt.Errorf("%w: max attribute value length must not be negative, got %d", ErrInvalidConfig, c.SpanLimits.MaxAttributeValueLength)
+	}
+
+	switch c.OTLPProtocol {
+	case "", OTLPProtocolGRPC:
+	default:
+		return fmt.Errorf("%w: unknown OTLP protocol %q", ErrInvalidConfig, c.OTLPProtocol)
+	}
+
+	if c.Exporter == nil && !c.UseStdout && c.JaegerEndpoint == "" {
+		if _, _, err := c.OTLPTarget(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+	}
+
+	switch c.Compression {
+	case "", CompressionNone, CompressionGzip:
+	default:
+		return fmt.Errorf("%w: unknown compression %q", ErrInvalidConfig, c.Compression)
+	}
+
+	switch c.ExportMode {
+	case "", ExportModeBatch, ExportModeSimple:
+	default:
+		return fmt.Errorf("%w: unknown export mode %q", ErrInvalidConfig, c.ExportMode)
+	}
+
+	if c.SyncExport && c.ExportMode == ExportModeBatch {
+		return fmt.Errorf("%w: sync export conflicts with export mode %q", ErrInvalidConfig, c.ExportMode)
+	}
+
+	switch c.MetricsExporter {
+	case "", MetricsExporterPrometheus:
+	default:
+		return fmt.Errorf("%w: unknown metrics exporter %q", ErrInvalidConfig, c.MetricsExporter)
+	}
+
+	return nil
+}
+
 // Provider is the OpenTelemetry provider that holds resources needed for telemetry
 type Provider struct {
 	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider
 	Config         Config
+
+	// metricsRegistry holds the Prometheus exporter's collector and is gathered on scrape
+	metricsRegistry *prometheus.Registry
+
+	// exemplars links duration histogram buckets to sampled traces
+	exemplars *exemplarReservoir
+
+	// stats counts spans passing through the export pipeline
+	stats *spanCounters
+
+	// propagator is the composite of the configured propagators
+	propagator propagation.TextMapPropagator
+}
+
+// defaultOTLPGRPCPort is the collector port used when OTLPEndpoint has none
+const defaultOTLPGRPCPort = "4317"
+
+// OTLPTarget returns the collector host:port the OTLP exporter dials and whether transport
+// security is disabled. A "http://" or "https://" scheme in OTLPEndpoint decides the latter,
+// otherwise Insecure does. Endpoints with a scheme but no port use the scheme's default port
+func (c Config) OTLPTarget() (address string, insecure bool, err error) {
+	endpoint := c.OTLPEndpoint
+	if !strings.Contains(endpoint, "://") {
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			// Bare host without a port
+			host, port = strings.Trim(endpoint, "[]"), defaultOTLPGRPCPort
+		}
+		if host == "" {
+			return "", false, fmt.Errorf("OTLP endpoint %q has no host", endpoint)
+		}
+		return net.JoinHostPort(host, port), c.Insecure, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid OTLP endpoint %q: %v", endpoint, err)
+	}
+	if u.Hostname() == "" {
+		return "", false, fmt.Errorf("OTLP endpoint %q has no host", endpoint)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return "", false, fmt.Errorf("OTLP endpoint %q has a path, which gRPC does not support", endpoint)
+	}
+
+	port := u.Port()
+	switch u.Scheme {
+	case "http":
+		insecure = true
+		if port == "" {
+			port = "80"
+		}
+	case "https":
+		insecure = false
+		if port == "" {
+			port = "443"
+		}
+	default:
+		return "", false, fmt.Errorf("OTLP endpoint %q has unsupported scheme %q", endpoint, u.Scheme)
+	}
+	return net.JoinHostPort(u.Hostname(), port), insecure, nil
+}
+
+// serviceRevisionKey is the resource attribute holding the deployed source revision
+const serviceRevisionKey = "service.revision"
+
+// buildRevision returns the VCS revision stamped into the binary by the Go toolchain, if any
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
 }
 
 // NewProvider creates and initializes a new OpenTelemetry provider
+// The config is cloned, so changing cfg afterwards does not affect the provider
 func NewProvider(cfg Config) (*Provider, error) {
+	cfg = cfg.Clone()
+
+	// Install a no-op tracer provider when tracing is disabled
+	if cfg.Disabled {
+		if !cfg.DisableGlobal {
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		}
+		return &Provider{Config: cfg}, nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 
 	// Create resource attributes
@@ -101,6 +489,17 @@ func NewProvider(cfg Config) (*Provider, error) {
 		})
 	}
 
+	revision := cfg.Revision
+	if revision == "" {
+		revision = buildRevision()
+	}
+	if revision != "" {
+		resourceAttrs = append(resourceAttrs, ResourceAttribute{
+			Key:   serviceRevisionKey,
+			Value: revision,
+		})
+	}
+
 	// Add user-provided attributes
 	resourceAttrs = append(resourceAttrs, cfg.AdditionalAttributes...)
 
@@ -110,28 +509,81 @@ func NewProvider(cfg Config) (*Provider, error) {
 		attrs = append(attrs, attribute.String(attr.Key, attr.Value))
 	}
 
-	// Create resource
-	res, err := resource.New(ctx, resource.WithAttributes(attrs...))
+	// Create resource, keeping whatever was detected if some detectors failed
+	res, err := resource.New(ctx,
+		resource.WithDetectors(cfg.ResourceDetectors...),
+		resource.WithAttributes(attrs...),
+	)
+	if errors.Is(err, resource.ErrPartialResource) {
+		cfg.logger().Warn(ctx, "resource detection partially failed", "error", err)
+		err = nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Create appropriate exporter based on configuration
 	var exporter sdktrace.SpanExporter
-	if cfg.UseStdout {
+	if cfg.Exporter != nil {
+		exporter = cfg.Exporter
+	} else if cfg.UseStdout {
 		exporter, err = stdouttrace.New(
 			stdouttrace.WithPrettyPrint(),
 		)
+	} else if cfg.JaegerEndpoint != "" {
+		exporter, err = jaeger.New(
+			jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(cfg.JaegerEndpoint)),
+		)
 	} else {
 		// Set up OTLP exporter
+		address, insecureTransport, targetErr := cfg.OTLPTarget()
+		if targetErr != nil {
+			return nil, targetErr
+		}
 		opts := []otlptracegrpc.Option{
-			otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint),
+			otlptracegrpc.WithEndpoint(address),
 		}
 
+		// WithDialOption replaces earlier dial options, so collect them and apply once
+		var dialOpts []grpc.DialOption
+
 		// Configure security options
-		if cfg.Insecure {
+		if insecureTransport {
 			opts = append(opts, otlptracegrpc.WithInsecure())
-			opts = append(opts, otlptracegrpc.WithDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+			dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		}
+
+		// Wait for the connection, bounded by the export timeout so startup cannot hang forever
+		dialCtx := ctx
+		if cfg.BlockOnConnect {
+			dialOpts = append(dialOpts, grpc.WithBlock())
+			if cfg.ExportTimeout > 0 {
+				var cancel context.CancelFunc
+				dialCtx, cancel = context.WithTimeout(ctx, cfg.ExportTimeout)
+				defer cancel()
+			}
+		}
+
+		// User dial options come last so they take precedence
+		dialOpts = append(dialOpts, cfg.ExtraDialOptions...)
+
+		if len(dialOpts) > 0 {
+			opts = append(opts, otlptracegrpc.WithDialOption(dialOpts...))
+		}
+
+		// Reuse an existing connection instead of dialing; dial options are then ignored
+		if cfg.GRPCConn != nil {
+			opts = append(opts, otlptracegrpc.WithGRPCConn(cfg.GRPCConn))
+		}
+
+		// Configure retries of failed exports
+		if cfg.Retry != nil {
+			opts = append(opts, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+				Enabled:         cfg.Retry.Enabled,
+				InitialInterval: cfg.Retry.InitialInterval,
+				MaxInterval:     cfg.Retry.MaxInterval,
+				MaxElapsedTime:  cfg.Retry.MaxElapsedTime,
+			}))
 		}
 
 		// Add headers if provided
@@ -141,45 +593,197 @@ func NewProvider(cfg Config) (*Provider, error) {
 			opts = append(opts, otlptracegrpc.WithHeaders(headers))
 		}
 
+		// Compress payloads if requested
+		if cfg.Compression == CompressionGzip {
+			opts = append(opts, otlptracegrpc.WithCompressor(CompressionGzip))
+		}
+
 		// Create OTLP client
 		client := otlptracegrpc.NewClient(opts...)
-		exporter, err = otlptrace.New(ctx, client)
+		exporter, err = otlptrace.New(dialCtx, client)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Create batch span processor
-	bsp := sdktrace.NewBatchSpanProcessor(
-		exporter,
-		sdktrace.WithBatchTimeout(cfg.BatchTimeout),
-		sdktrace.WithMaxExportBatchSize(cfg.BatchSize),
-	)
+	// Scrub sensitive attribute values before they reach the exporter
+	if len(cfg.RedactAttributes) > 0 {
+		exporter = NewRedactingExporter(cfg.RedactAttributes, exporter)
+	}
 
-	// Create trace provider
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	// Export synchronously when requested, otherwise batch spans, counting spans on both
+	// sides of the processor so drops can be reported by Stats
+	stats := &spanCounters{}
+	countedExporter := countingExporter{SpanExporter: exporter, counters: stats, logger: cfg.logger()}
+	var processor sdktrace.SpanProcessor
+	if cfg.SyncExport || cfg.ExportMode == ExportModeSimple {
+		processor = sdktrace.NewSimpleSpanProcessor(countedExporter)
+	} else {
+		bspOpts := []sdktrace.BatchSpanProcessorOption{
+			sdktrace.WithBatchTimeout(cfg.BatchTimeout),
+			sdktrace.WithMaxExportBatchSize(cfg.BatchSize),
+		}
+		if cfg.MaxQueueSize > 0 {
+			bspOpts = append(bspOpts, sdktrace.WithMaxQueueSize(cfg.MaxQueueSize))
+		}
+		if cfg.ExportTimeout > 0 {
+			bspOpts = append(bspOpts, sdktrace.WithExportTimeout(cfg.ExportTimeout))
+		}
+		if cfg.BlockOnQueueFull {
+			bspOpts = append(bspOpts, sdktrace.WithBlocking())
+		}
+		processor = sdktrace.NewBatchSpanProcessor(countedExporter, bspOpts...)
+	}
+	processor = countingProcessor{SpanProcessor: processor, counters: stats}
+
+	// Record unsampled spans so slow ones can still be exported through the same processor
+	if cfg.SlowRequestThreshold > 0 {
+		processor = newSlowSpanProcessor(processor, cfg.SlowRequestThreshold)
+	}
+
+	// Use the configured sampler, falling back to the sample ratio or always sampling
+	sampler := cfg.Sampler
+	if sampler == nil && cfg.SampleRatio > 0 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))
+	}
+	if sampler == nil {
+		sampler = sdktrace.AlwaysSample()
+	}
+	sampler = forceSampler{base: sampler}
+	if cfg.SlowRequestThreshold > 0 {
+		sampler = recordingSampler{base: sampler}
+	}
+
+	// Apply configured span limits on top of the SDK defaults
+	limits := sdktrace.NewSpanLimits()
+	if cfg.SpanLimits.MaxAttributeCount > 0 {
+		limits.AttributeCountLimit = cfg.SpanLimits.MaxAttributeCount
+	}
+	if cfg.SpanLimits.MaxAttributeValueLength > 0 {
+		limits.AttributeValueLengthLimit = cfg.SpanLimits.MaxAttributeValueLength
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithRawSpanLimits(limits),
+	}
+	if cfg.IDGenerator != nil {
+		providerOpts = append(providerOpts, sdktrace.WithIDGenerator(cfg.IDGenerator))
+	}
+	for _, sp := range cfg.ExtraSpanProcessors {
+		providerOpts = append(providerOpts, sdktrace.WithSpanProcessor(sp))
+	}
 
-	// Set global provider and propagator
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
+	// Create trace provider
+	tp := sdktrace.NewTracerProvider(append(providerOpts, sdktrace.WithSampler(sampler))...)
+
+	// Use the configured propagators, falling back to W3C trace context and baggage
+	propagators := cfg.Propagators
+	if len(propagators) == 0 {
+		propagators = []propagation.TextMapPropagator{
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		}
+	}
+	propagator := propagation.NewCompositeTextMapPropagator(propagators...)
 
-	return &Provider{
+	provider := &Provider{
 		TracerProvider: tp,
 		Config:         cfg,
-	}, nil
+		propagator:     propagator,
+		stats:          stats,
+	}
+
+	// Create meter provider if a metrics exporter is configured
+	if cfg.MetricsExporter == MetricsExporterPrometheus {
+		registry := prometheus.NewRegistry()
+		reader, err := otelprometheus.New(
+			otelprometheus.WithRegisterer(registry),
+			otelprometheus.WithoutScopeInfo(),
+		)
+		if err != nil {
+			_ = tp.Shutdown(ctx)
+			return nil, err
+		}
+		provider.metricsRegistry = registry
+		provider.exemplars = newExemplarReservoir()
+		provider.MeterProvider = sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithReader(reader),
+		)
+	}
+
+	// Set global providers and propagator
+	if !cfg.DisableGlobal {
+		provider.registerGlobals()
+	}
+
+	return provider, nil
+}
+
+// registerGlobals installs the provider's tracer provider, propagator and meter provider as
+// the OpenTelemetry globals
+func (p *Provider) registerGlobals() {
+	otel.SetTracerProvider(p.TracerProvider)
+	otel.SetTextMapPropagator(p.propagator)
+	if p.MeterProvider != nil {
+		otel.SetMeterProvider(p.MeterProvider)
+	}
+}
+
+// instrumentationName returns the configured instrumentation scope name, or the default
+func (p *Provider) instrumentationName() string {
+	if p.Config.InstrumentationName == "" {
+		return tracerNameValue
+	}
+	return p.Config.InstrumentationName
+}
+
+// tracer returns the provider's instrumentation tracer, or a no-op tracer if tracing is disabled
+func (p *Provider) tracer() trace.Tracer {
+	if p.TracerProvider == nil {
+		return trace.NewNoopTracerProvider().Tracer(p.instrumentationName())
+	}
+	return p.TracerProvider.Tracer(p.instrumentationName(),
+		trace.WithInstrumentationVersion(p.Config.InstrumentationVersion),
+	)
+}
+
+// withInstrumentation stores the provider's tracer name and version in ctx
+func (p *Provider) withInstrumentation(ctx context.Context) context.Context {
+	return withInstrumentation(ctx, p.instrumentationName(), p.Config.InstrumentationVersion)
+}
+
+// textMapPropagator returns the provider's propagator, or the global one if none was configured
+func (p *Provider) textMapPropagator() propagation.TextMapPropagator {
+	if p.propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+	return p.propagator
+}
+
+// ForceFlush immediately exports all spans that have not yet been exported
+// It returns early with the context's error if the context deadline is exceeded
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	var err error
+	if p.TracerProvider != nil {
+		err = p.TracerProvider.ForceFlush(ctx)
+	}
+	if p.MeterProvider != nil {
+		err = errors.Join(err, p.MeterProvider.ForceFlush(ctx))
+	}
+	return err
 }
 
 // Shutdown gracefully shuts down the provider
 func (p *Provider) Shutdown(ctx context.Context) error {
+	var err error
 	if p.TracerProvider != nil {
-		return p.TracerProvider.Shutdown(ctx)
+		err = p.TracerProvider.Shutdown(ctx)
 	}
-	return nil
+	if p.MeterProvider != nil {
+		err = errors.Join(err, p.MeterProvider.Shutdown(ctx))
+	}
+	return err
 }
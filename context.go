@@ -1,14 +1,56 @@
 package vayuotel
 
+import (
+	"context"
+	"time"
+
+	"github.com/kaushiksamanta/vayu"
+)
+
 // contextKey is a private type for context keys used by the vayuotel package
 type contextKey int
 
 // Context keys for storing OpenTelemetry-related values in the request context
 const (
-	tracerNameKey   contextKey = iota
-	tracerNameValue string     = "vayu-http"
+	tracerNameKey contextKey = iota
+
+	// tracerVersionKey holds the instrumentation version paired with the tracer name
+	tracerVersionKey
+
+	// tracerProviderKey holds the tracer provider used for root spans started with Start
+	tracerProviderKey
+
+	// requestDurationKey holds the handler duration measured by the middleware
+	requestDurationKey
+
+	// routeKey holds the matched route template, read by RouteSampler
+	routeKey
+
+	// forceSampleKey marks requests that must be sampled regardless of the configured sampler
+	forceSampleKey
+
+	// matchedRouteKey holds the *matchedRoute filled in by TraceHandler
+	matchedRouteKey
+
+	// routePriorityKey holds the request priority computed by MiddlewareOptions.RoutePriority
+	routePriorityKey
+
+	// suppressTracingKey marks contexts in which Start returns no-op spans
+	suppressTracingKey
+
+	// serverSpanKey holds the server span started by the middleware, which handler spans
+	// such as TraceHandler's replace as the context's current span
+	serverSpanKey
 )
 
+// matchedRoute records the route template of the handler that served a request
+type matchedRoute struct {
+	template string
+}
+
+// tracerNameValue is the default tracer name used by the middleware
+const tracerNameValue string = "vayu-http"
+
 // GetTracerNameKey returns the context key used for storing the tracer name
 // This is primarily used for testing
 func GetTracerNameKey() contextKey {
@@ -19,3 +61,47 @@ func GetTracerNameKey() contextKey {
 func GetDefaultTracerName() string {
 	return tracerNameValue
 }
+
+// withInstrumentation stores the tracer name and version in ctx so Start uses the same scope
+func withInstrumentation(ctx context.Context, name, version string) context.Context {
+	ctx = context.WithValue(ctx, tracerNameKey, name)
+	if version != "" {
+		ctx = context.WithValue(ctx, tracerVersionKey, version)
+	}
+	return ctx
+}
+
+// StoreRequestDuration records the time spent in the handler chain on the request context,
+// so middleware running outside the tracing middleware (e.g. access logs) can read it
+func StoreRequestDuration(c *vayu.Context, d time.Duration) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestDurationKey, d))
+}
+
+// RequestDuration returns the duration stored by StoreRequestDuration
+func RequestDuration(c *vayu.Context) (time.Duration, bool) {
+	d, ok := c.Request.Context().Value(requestDurationKey).(time.Duration)
+	return d, ok
+}
+
+// ContextWithTimeout derives a context with a timeout from the request context, so database
+// queries and other calls made with it stay part of the request's trace
+func ContextWithTimeout(c *vayu.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if c != nil && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// DetachedContext returns a context that keeps the values of ctx (including the active span
+// and tracer name) but is not cancelled when ctx is, so background goroutines started from
+// a handler stay part of the trace after the request completes
+func DetachedContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// SuppressTracing returns a context in which Start and its variants produce no-op spans,
+// e.g. to avoid a span per iteration of a hot loop inside a handler
+func SuppressTracing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressTracingKey, true)
+}
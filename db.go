@@ -0,0 +1,50 @@
+package vayuotel
+
+import (
+	"context"
+	"unicode/utf8"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxDBStatementLength is the maximum length of db.statement attributes set by StartDBSpan
+// Longer statements are truncated to keep span sizes bounded
+const MaxDBStatementLength = 2048
+
+// StartDBSpan starts a client span for a database call named "<system> <operation>"
+// with the standard db.system, db.operation and db.statement attributes
+func StartDBSpan(ctx context.Context, system, operation, statement string, opts ...SpanOption) *Span {
+	name := system
+	if operation != "" {
+		name = system + " " + operation
+	}
+
+	span := startSpan(ctx, name, []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(system)),
+	}, opts...)
+
+	if operation != "" {
+		span.Span.SetAttributes(semconv.DBOperationKey.String(operation))
+	}
+
+	if statement != "" {
+		span.Span.SetAttributes(semconv.DBStatementKey.String(truncateString(statement, MaxDBStatementLength)))
+	}
+
+	return span
+}
+
+// truncateString shortens s to at most limit bytes without splitting a UTF-8 character
+func truncateString(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+
+	s = s[:limit]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
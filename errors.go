@@ -1,6 +1,11 @@
 package vayuotel
 
-import "errors"
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
 
 // Common errors returned by the vayuotel package.
 var (
@@ -10,6 +15,44 @@ var (
 	// ErrInvalidConfig is returned when the provided configuration is invalid
 	ErrInvalidConfig = errors.New("invalid OpenTelemetry configuration")
 
+	// ErrCollectorUnreachable is returned by HealthCheck when the collector cannot be reached
+	ErrCollectorUnreachable = errors.New("OpenTelemetry collector unreachable")
+
 	// ErrProviderNotInitialized is returned when trying to use the provider before initialization
 	ErrProviderNotInitialized = errors.New("OpenTelemetry provider not initialized")
 )
+
+// TracedError wraps an error with the trace and span IDs that were active where it occurred,
+// so a central error handler can log the originating trace
+type TracedError struct {
+	err         error
+	spanContext trace.SpanContext
+}
+
+// WrapError captures the span context active in ctx alongside err. A nil err returns nil
+func WrapError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TracedError{err: err, spanContext: trace.SpanContextFromContext(ctx)}
+}
+
+// Error implements error
+func (e *TracedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *TracedError) Unwrap() error {
+	return e.err
+}
+
+// TraceID returns the trace ID active when the error was wrapped
+func (e *TracedError) TraceID() trace.TraceID {
+	return e.spanContext.TraceID()
+}
+
+// SpanID returns the span ID active when the error was wrapped
+func (e *TracedError) SpanID() trace.SpanID {
+	return e.spanContext.SpanID()
+}
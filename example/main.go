@@ -113,8 +113,7 @@ func main() {
 		span4.End()
 
 		// Create span5 as a sibling of span2 (child of span1)
-		// Use the span1 context to make it a direct child of span1
-		span5 := vayuOtel.Start(ctx, "/span-hierarchy/child-span5")
+		span5 := vayuOtel.StartSibling(span2, "/span-hierarchy/child-span5")
 		span5.AddAttributes(map[string]interface{}{
 			"span.type": "sibling",
 		})
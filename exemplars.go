@@ -0,0 +1,79 @@
+package vayuotel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxExemplarsPerSeries bounds how many recent exemplars are kept for each attribute set
+const maxExemplarsPerSeries = 16
+
+// maxExemplarSeries bounds how many attribute sets the reservoir tracks; measurements of
+// further series are not kept as exemplars
+const maxExemplarSeries = 1024
+
+// exemplarReservoir keeps recent sampled measurements of a histogram so scrapes can link
+// buckets to example traces. The pinned metrics SDK does not collect exemplars itself
+type exemplarReservoir struct {
+	mu     sync.Mutex
+	series map[attribute.Distinct][]metricdata.Exemplar[float64]
+}
+
+// newExemplarReservoir creates an empty reservoir
+func newExemplarReservoir() *exemplarReservoir {
+	return &exemplarReservoir{series: make(map[attribute.Distinct][]metricdata.Exemplar[float64])}
+}
+
+// offer records value as an exemplar of the series attrs if the span in ctx is sampled
+func (r *exemplarReservoir) offer(ctx context.Context, value float64, attrs attribute.Set) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return
+	}
+
+	traceID := sc.TraceID()
+	spanID := sc.SpanID()
+	exemplar := metricdata.Exemplar[float64]{
+		Time:    time.Now(),
+		Value:   value,
+		TraceID: traceID[:],
+		SpanID:  spanID[:],
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := attrs.Equivalent()
+	if _, ok := r.series[key]; !ok && len(r.series) >= maxExemplarSeries {
+		return
+	}
+	exemplars := append(r.series[key], exemplar)
+	if len(exemplars) > maxExemplarsPerSeries {
+		exemplars = exemplars[len(exemplars)-maxExemplarsPerSeries:]
+	}
+	r.series[key] = exemplars
+}
+
+// lookup returns a copy of the exemplars stored for the series attrs
+func (r *exemplarReservoir) lookup(attrs attribute.Set) []metricdata.Exemplar[float64] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]metricdata.Exemplar[float64](nil), r.series[attrs.Equivalent()]...)
+}
+
+// bucketExemplar returns the most recent exemplar whose value falls in the bucket (lower, upper]
+func bucketExemplar[N int64 | float64](exemplars []metricdata.Exemplar[N], lower, upper float64) (metricdata.Exemplar[N], bool) {
+	for i := len(exemplars) - 1; i >= 0; i-- {
+		v := float64(exemplars[i].Value)
+		if v > lower && v <= upper {
+			return exemplars[i], true
+		}
+	}
+	return metricdata.Exemplar[N]{}, false
+}
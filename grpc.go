@@ -0,0 +1,91 @@
+package vayuotel
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a gRPC interceptor that traces unary calls using the
+// integration's provider, continuing traces propagated through incoming metadata
+func (i *Integration) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	// Get the tracer
+	tracer := i.provider.tracer()
+	propagator := i.provider.textMapPropagator()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// Extract trace context from the incoming metadata
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = propagator.Extract(ctx, metadataCarrier(md))
+
+		// Start a new server span named after the full method
+		ctx, span := tracer.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		service, method := splitFullMethod(info.FullMethod)
+		span.SetAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		)
+
+		// Store the tracer name in the context so Start works in handlers
+		ctx = i.provider.withInstrumentation(ctx)
+
+		// Call the handler
+		resp, err := handler(ctx, req)
+
+		// Record the gRPC status code
+		st, _ := status.FromError(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+
+		// Mark span as error for non-OK statuses
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, st.Message())
+		}
+
+		return resp, err
+	}
+}
+
+// splitFullMethod splits a full gRPC method name ("/package.Service/Method") into service and method
+func splitFullMethod(fullMethod string) (string, string) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// metadataCarrier adapts gRPC metadata to the propagation.TextMapCarrier interface
+type metadataCarrier metadata.MD
+
+// Get returns the first value associated with the key
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set stores the key-value pair
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys lists the keys stored in the carrier
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
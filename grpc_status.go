@@ -0,0 +1,45 @@
+package vayuotel
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// DefaultGRPCStatusMapper maps an HTTP status code to the gRPC status code a gateway would
+// translate it from (e.g. 404 to NOT_FOUND), for MiddlewareOptions.GRPCStatusMapper
+func DefaultGRPCStatusMapper(httpStatus int) int {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return int(codes.InvalidArgument)
+	case http.StatusUnauthorized:
+		return int(codes.Unauthenticated)
+	case http.StatusForbidden:
+		return int(codes.PermissionDenied)
+	case http.StatusNotFound:
+		return int(codes.NotFound)
+	case http.StatusConflict:
+		return int(codes.Aborted)
+	case http.StatusPreconditionFailed:
+		return int(codes.FailedPrecondition)
+	case http.StatusRequestedRangeNotSatisfiable:
+		return int(codes.OutOfRange)
+	case http.StatusTooManyRequests:
+		return int(codes.ResourceExhausted)
+	case 499: // Client closed request
+		return int(codes.Canceled)
+	case http.StatusInternalServerError:
+		return int(codes.Internal)
+	case http.StatusNotImplemented:
+		return int(codes.Unimplemented)
+	case http.StatusServiceUnavailable:
+		return int(codes.Unavailable)
+	case http.StatusGatewayTimeout:
+		return int(codes.DeadlineExceeded)
+	}
+
+	if httpStatus >= 200 && httpStatus < 300 {
+		return int(codes.OK)
+	}
+	return int(codes.Unknown)
+}
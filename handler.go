@@ -0,0 +1,24 @@
+package vayuotel
+
+import (
+	"net/http"
+
+	"github.com/kaushiksamanta/vayu"
+)
+
+// WrapHandler wraps a standard http.Handler (e.g. a file server) with the same tracing as the
+// Vayu middleware, so handlers mounted outside Vayu's routing are traced too
+func (i *Integration) WrapHandler(next http.Handler) http.Handler {
+	middleware := i.Middleware()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := &vayu.Context{
+			Request: r,
+			Writer:  w,
+			Params:  make(map[string]string),
+		}
+		middleware(c, func() {
+			next.ServeHTTP(c.Writer, c.Request)
+		})
+	})
+}
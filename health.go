@@ -0,0 +1,68 @@
+package vayuotel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// HealthCheck verifies that the configured collector accepts connections and returns a
+// descriptive error wrapping ErrCollectorUnreachable if it does not. It is a no-op for
+// disabled providers and for stdout or custom exporters
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	cfg := p.Config
+	if cfg.Disabled || cfg.Exporter != nil || cfg.UseStdout {
+		return nil
+	}
+
+	address, err := collectorAddress(cfg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCollectorUnreachable, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("%w: cannot connect to %s: %v", ErrCollectorUnreachable, address, err)
+	}
+	return conn.Close()
+}
+
+// collectorAddress returns the host:port the configured exporter sends spans to
+func collectorAddress(cfg Config) (string, error) {
+	if cfg.JaegerEndpoint == "" {
+		address, _, err := cfg.OTLPTarget()
+		return address, err
+	}
+
+	u, err := url.Parse(cfg.JaegerEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid Jaeger endpoint %q: %v", cfg.JaegerEndpoint, err)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "80"), nil
+}
+
+// HealthHandler returns an http.Handler for readiness probes (e.g. mounted at /readyz). It
+// responds 200 once the most recent span export succeeded, and 503 before the first export
+// or after a failed one. Disabled providers always report ready
+func (i *Integration) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case i.provider == nil:
+			http.Error(w, ErrProviderNotInitialized.Error(), http.StatusServiceUnavailable)
+		case i.provider.Config.Disabled || i.provider.Stats().LastExportSucceeded:
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "ok")
+		default:
+			http.Error(w, "no successful span export", http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -1,7 +1,18 @@
 package vayuotel
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 )
 
 // Helper function to get the scheme from the request
@@ -18,3 +29,203 @@ func getScheme(r *http.Request) string {
 	// Default to http
 	return "http"
 }
+
+// Helper function to get the client IP from the request
+// Forwarding headers take precedence; for X-Forwarded-For the left-most (original client) entry is used
+func getClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		client, _, _ := strings.Cut(forwarded, ",")
+		if client = strings.TrimSpace(client); client != "" {
+			return client
+		}
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+
+	// Fall back to the remote address of the connection
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Helper function to get the protocol version from the request (e.g. "1.1", "2")
+func getProtocolVersion(r *http.Request) string {
+	if r.ProtoMajor >= 2 && r.ProtoMinor == 0 {
+		return strconv.Itoa(r.ProtoMajor)
+	}
+	return strconv.Itoa(r.ProtoMajor) + "." + strconv.Itoa(r.ProtoMinor)
+}
+
+// isWebSocketUpgrade reports whether the request asks to upgrade the connection to WebSocket
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// matchRoute returns the most specific of the route templates (e.g. "/users/:id") that matches
+// path, or "" if none does. A ":name" segment matches any one segment and a trailing "*" or
+// "*name" segment matches the rest of the path. Templates with more static segments win, and
+// ties go to the template listed first, so the result never depends on map order
+func matchRoute(path string, templates []string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	best, bestScore := "", -1
+	for _, template := range templates {
+		if score, ok := routeScore(segments, strings.Split(strings.Trim(template, "/"), "/")); ok && score > bestScore {
+			best, bestScore = template, score
+		}
+	}
+	return best
+}
+
+// routeScore reports whether the template segments match the path segments and, if so,
+// how many of them are static
+func routeScore(segments, template []string) (int, bool) {
+	score := 0
+	for i, part := range template {
+		if strings.HasPrefix(part, "*") && i == len(template)-1 {
+			return score, true
+		}
+		if i >= len(segments) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(part, ":") && segments[i] != "":
+		case part == segments[i]:
+			score++
+		default:
+			return 0, false
+		}
+	}
+	return score, len(segments) == len(template)
+}
+
+// headerAttributes returns span attributes for the allowlisted headers present in the request
+func headerAttributes(r *http.Request, allowlist []string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(allowlist))
+	for _, name := range allowlist {
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		key := "http.request.header." + strings.ReplaceAll(strings.ToLower(name), "-", "_")
+		attrs = append(attrs, attribute.StringSlice(key, values))
+	}
+	return attrs
+}
+
+// baggageAttributes returns span attributes for the listed baggage members present in ctx
+func baggageAttributes(ctx context.Context, keys []string) []attribute.KeyValue {
+	bag := baggage.FromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		member := bag.Member(key)
+		if member.Key() == "" {
+			continue
+		}
+		attrs = append(attrs, attribute.String("baggage."+key, member.Value()))
+	}
+	return attrs
+}
+
+// redactedValue replaces the values of sensitive fields recorded on spans
+const redactedValue = "[REDACTED]"
+
+// isRedactedKey reports whether the query parameter key is one of redact (case-insensitive)
+func isRedactedKey(key string, redact []string) bool {
+	for _, redacted := range redact {
+		if strings.EqualFold(key, redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL returns a copy of u whose query values of redacted keys are replaced,
+// keeping the order of the query parameters
+func redactURL(u *url.URL, redact []string) *url.URL {
+	if u.RawQuery == "" || len(redact) == 0 {
+		return u
+	}
+
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if isRedactedKey(key, redact) {
+			pairs[i] = rawKey + "=" + redactedValue
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = strings.Join(pairs, "&")
+	return &redacted
+}
+
+// queryAttributes returns span attributes for the request's query parameters,
+// replacing the values of redacted keys
+func queryAttributes(r *http.Request, redact []string) []attribute.KeyValue {
+	query := r.URL.Query()
+	attrs := make([]attribute.KeyValue, 0, len(query))
+	for key, values := range query {
+		value := strings.Join(values, ",")
+		if isRedactedKey(key, redact) {
+			value = redactedValue
+		}
+		attrs = append(attrs, attribute.String("http.query."+key, value))
+	}
+	return attrs
+}
+
+// peekRequestBody reads up to limit bytes of the request body if its media type is one of
+// contentTypes, and puts them back in front of the unread rest so the handler still sees the
+// whole body. It reports whether the body was captured and whether it was truncated
+func peekRequestBody(r *http.Request, limit int, contentTypes []string) (body []byte, truncated, ok bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false, false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false, false
+	}
+	matched := false
+	for _, contentType := range contentTypes {
+		if strings.EqualFold(mediaType, contentType) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil, false, false
+	}
+
+	// Read one byte past the limit to detect truncation
+	buf, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), r.Body), Closer: r.Body}
+	if err != nil {
+		return nil, false, false
+	}
+
+	if len(buf) > limit {
+		return buf[:limit], true, true
+	}
+	return buf, false, true
+}
+
+// readCloser combines a reader with the closer of the body it replaces
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// statusClass returns the class of a status code for grouping by outcome (e.g. 503 is "5xx")
+func statusClass(statusCode int) string {
+	return strconv.Itoa(statusCode/100) + "xx"
+}
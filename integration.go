@@ -2,14 +2,23 @@ package vayuotel
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/kaushiksamanta/vayu"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultShutdownTimeout bounds ShutdownWithTimeout when no timeout is given
+const DefaultShutdownTimeout = 5 * time.Second
+
 // Integration provides an easy-to-use integration with the Vayu framework
 type Integration struct {
 	provider *Provider
 	app      *vayu.App
+
+	// routes are the route templates matched against request paths
+	routes routeRegistry
 }
 
 // SetupOptions contains the options for setting up the integration
@@ -38,20 +47,83 @@ func Setup(options SetupOptions) (*Integration, error) {
 		app: options.App,
 	}
 
-	// Initialize provider
-	provider, err := NewProvider(options.Config)
+	// Initialize provider, registering the globals only once the collector is known to be reachable
+	cfg := options.Config
+	deferGlobals := cfg.VerifyConnection && !cfg.Disabled && !cfg.DisableGlobal
+	if deferGlobals {
+		cfg.DisableGlobal = true
+	}
+	provider, err := NewProvider(cfg)
 	if err != nil {
 		return nil, err
 	}
+	provider.Config.DisableGlobal = options.Config.DisableGlobal
 	integration.provider = provider
 
+	// Fail fast if the collector is unreachable
+	if options.Config.VerifyConnection {
+		ctx := context.Background()
+		if options.Config.ExportTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.Config.ExportTimeout)
+			defer cancel()
+		}
+
+		if err := provider.HealthCheck(ctx); err != nil {
+			return nil, errors.Join(err, provider.Shutdown(ctx))
+		}
+	}
+
+	if deferGlobals {
+		provider.registerGlobals()
+	}
+
 	return integration, nil
 }
 
+// Provider returns the integration's OpenTelemetry provider
+func (i *Integration) Provider() *Provider {
+	return i.provider
+}
+
+// TracerProvider returns the tracer provider backing the integration, so other OpenTelemetry
+// instrumentation (e.g. database plugins) can share the same pipeline
+// A no-op tracer provider is returned when tracing is disabled
+func (i *Integration) TracerProvider() trace.TracerProvider {
+	if i.provider == nil || i.provider.TracerProvider == nil {
+		return trace.NewNoopTracerProvider()
+	}
+	return i.provider.TracerProvider
+}
+
+// BackgroundContext returns a context for background jobs in which Start creates root spans
+// using the integration's tracer provider and instrumentation name
+func (i *Integration) BackgroundContext() context.Context {
+	if i.provider == nil {
+		return context.Background()
+	}
+	ctx := context.WithValue(context.Background(), tracerProviderKey, i.TracerProvider())
+	return i.provider.withInstrumentation(ctx)
+}
+
 // Shutdown gracefully shuts down the OpenTelemetry integration
+// Buffered spans are force-flushed before the provider is shut down
 func (i *Integration) Shutdown(ctx context.Context) error {
 	if i.provider != nil {
-		return i.provider.Shutdown(ctx)
+		flushErr := i.provider.ForceFlush(ctx)
+		return errors.Join(flushErr, i.provider.Shutdown(ctx))
 	}
 	return nil
 }
+
+// ShutdownWithTimeout shuts down the integration like Shutdown, giving up after d so an
+// unreachable collector cannot hang the process. A zero d uses DefaultShutdownTimeout
+func (i *Integration) ShutdownWithTimeout(d time.Duration) error {
+	if d <= 0 {
+		d = DefaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return i.Shutdown(ctx)
+}
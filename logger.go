@@ -0,0 +1,31 @@
+package vayuotel
+
+import "context"
+
+// Logger receives internal diagnostics that do not cause an operation to fail,
+// such as partial resource detection, failed span exports or metric instruments that could
+// not be created
+type Logger interface {
+	// Warn reports a recoverable problem
+	Warn(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	// Error reports a failure that was handled internally
+	Error(ctx context.Context, msg string, keysAndValues ...interface{})
+}
+
+// noopLogger discards all diagnostics
+type noopLogger struct{}
+
+// Warn implements Logger
+func (noopLogger) Warn(ctx context.Context, msg string, keysAndValues ...interface{}) {}
+
+// Error implements Logger
+func (noopLogger) Error(ctx context.Context, msg string, keysAndValues ...interface{}) {}
+
+// logger returns the configured logger, or a no-op logger if none is set
+func (c Config) logger() Logger {
+	if c.Logger == nil {
+		return noopLogger{}
+	}
+	return c.Logger
+}
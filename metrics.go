@@ -0,0 +1,124 @@
+package vayuotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaushiksamanta/vayu"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Metric names recorded by the metrics middleware
+const (
+	metricRequestCount    = "http.server.request_count"
+	metricRequestDuration = "http.server.duration"
+	metricActiveRequests  = "http.server.active_requests"
+	metricRequestSize     = "http.server.request.size"
+	metricResponseSize    = "http.server.response.size"
+)
+
+// unmatchedRouteLabel is the http.route metric label of requests that matched no route template
+const unmatchedRouteLabel = "[unmatched]"
+
+// MetricsMiddleware returns a Vayu middleware that records request rate, errors (via the
+// status code dimension), duration and body size metrics. It is a pass-through if metrics are disabled
+func (i *Integration) MetricsMiddleware() vayu.HandlerFunc {
+	if i.provider.MeterProvider == nil {
+		return func(c *vayu.Context, next vayu.NextFunc) {
+			next()
+		}
+	}
+
+	// Get the meter and create the instruments
+	meter := i.provider.MeterProvider.Meter(i.provider.instrumentationName(),
+		metric.WithInstrumentationVersion(i.provider.Config.InstrumentationVersion),
+	)
+
+	// Instruments that fail to be created are no-ops; report why to the configured logger
+	logger := i.provider.Config.logger()
+	reportError := func(instrument string, err error) {
+		if err != nil {
+			logger.Error(context.Background(), "failed to create metric instrument", "instrument", instrument, "error", err)
+		}
+	}
+
+	requestCount, err := meter.Int64Counter(metricRequestCount,
+		metric.WithDescription("Number of HTTP requests handled"),
+	)
+	reportError(metricRequestCount, err)
+
+	requestDuration, err := meter.Float64Histogram(metricRequestDuration,
+		metric.WithDescription("Duration of HTTP requests"),
+		metric.WithUnit("ms"),
+	)
+	reportError(metricRequestDuration, err)
+
+	activeRequests, err := meter.Int64UpDownCounter(metricActiveRequests,
+		metric.WithDescription("Number of HTTP requests currently being handled"),
+	)
+	reportError(metricActiveRequests, err)
+
+	requestSize, err := meter.Int64Histogram(metricRequestSize,
+		metric.WithDescription("Size of HTTP request bodies"),
+		metric.WithUnit("By"),
+	)
+	reportError(metricRequestSize, err)
+
+	responseSize, err := meter.Int64Histogram(metricResponseSize,
+		metric.WithDescription("Size of HTTP response bodies"),
+		metric.WithUnit("By"),
+	)
+	reportError(metricResponseSize, err)
+
+	return func(c *vayu.Context, next vayu.NextFunc) {
+		start := time.Now()
+		route := i.routes.match(c.Request.URL.Path)
+		if route == "" {
+			// Label unmatched paths as one series instead of one per raw path
+			route = unmatchedRouteLabel
+		}
+
+		// Track in-flight requests; the deferred decrement also runs if the handler panics
+		routeAttrs := metric.WithAttributes(
+			attribute.String("http.method", c.Request.Method),
+			attribute.String("http.route", route),
+		)
+		activeRequests.Add(c.Request.Context(), 1, routeAttrs)
+		defer activeRequests.Add(c.Request.Context(), -1, routeAttrs)
+
+		// Wrap the response writer to capture the status code
+		recorder := NewTracingResponseWriter(c.Writer)
+		c.Writer = recorder
+
+		// Call the next handler
+		next()
+
+		elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+		attrSet := durationAttributes(c.Request.Method, route, recorder.StatusCode())
+		attrs := metric.WithAttributeSet(attrSet)
+
+		// The request context carries the span if the tracing middleware ran inside this one
+		ctx := c.Request.Context()
+		requestCount.Add(ctx, 1, attrs)
+		requestDuration.Record(ctx, elapsed, attrs)
+		if i.provider.exemplars != nil {
+			i.provider.exemplars.offer(ctx, elapsed, attrSet)
+		}
+
+		// Body sizes are broken down by method and route only; requests of unknown length are skipped
+		if c.Request.ContentLength >= 0 {
+			requestSize.Record(ctx, c.Request.ContentLength, routeAttrs)
+		}
+		responseSize.Record(ctx, recorder.BytesWritten(), routeAttrs)
+	}
+}
+
+// durationAttributes returns the attribute set of the request count and duration series
+func durationAttributes(method, route string, status int) attribute.Set {
+	return attribute.NewSet(
+		attribute.String("http.method", method),
+		attribute.String("http.route", route),
+		attribute.Int("http.status_code", status),
+	)
+}
@@ -3,15 +3,26 @@ package vayuotel
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/kaushiksamanta/vayu"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Middleware returns a Vayu middleware function that automatically traces HTTP requests
 func (i *Integration) Middleware(options ...MiddlewareOptions) vayu.HandlerFunc {
+	// Skip tracing entirely when disabled
+	if i.provider.Config.Disabled {
+		return func(c *vayu.Context, next vayu.NextFunc) {
+			next()
+		}
+	}
+
 	// Use default options if none are provided
 	opts := DefaultMiddlewareOptions()
 	if len(options) > 0 {
@@ -25,36 +36,131 @@ func (i *Integration) Middleware(options ...MiddlewareOptions) vayu.HandlerFunc
 		}
 	}
 
-	// Get the tracer
-	tracer := i.provider.TracerProvider.Tracer(tracerNameValue)
+	// Use default error status predicate if not provided
+	if opts.ErrorStatusPredicate == nil {
+		opts.ErrorStatusPredicate = defaultErrorStatusPredicate
+	}
+
+	// Use default body capture limit if not provided
+	if opts.ResponseBodyCaptureLimit <= 0 {
+		opts.ResponseBodyCaptureLimit = DefaultResponseBodyCaptureLimit
+	}
+
+	// Use the default route param prefix if not provided
+	if opts.RouteParamPrefix == "" {
+		opts.RouteParamPrefix = DefaultRouteParamPrefix
+	}
+
+	// Use default path segment patterns if not provided
+	if opts.SanitizeSpanName && opts.PathSegmentPatterns == nil {
+		opts.PathSegmentPatterns = DefaultPathSegmentPatterns()
+	}
+
+	// Use the system clock if not provided
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+
+	// Get the tracer and propagator
+	tracer := i.provider.tracer()
+	propagator := i.provider.textMapPropagator()
 
 	// Return the middleware function
 	return func(c *vayu.Context, next vayu.NextFunc) {
-		// Extract trace context from the incoming request headers
-		propagator := propagation.TraceContext{}
+		// Extract trace context from the incoming request headers using the configured propagators
 		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
 
+		// Make the matched route available to route-aware samplers
+		route := i.routes.match(c.Request.URL.Path)
+		ctx = context.WithValue(ctx, routeKey, route)
+
+		// Make the request priority available to PrioritySampler
+		if opts.RoutePriority != nil {
+			ctx = context.WithValue(ctx, routePriorityKey, opts.RoutePriority(c))
+		}
+
+		// Let the sampler force sampling of debug requests
+		if opts.ForceSampleOnDebugHeader && c.Request.Header.Get(DebugTraceHeader) == "1" {
+			ctx = context.WithValue(ctx, forceSampleKey, true)
+		}
+
 		// Create the span name
 		spanName := opts.SpanNameFormatter(c)
+		if opts.SanitizeSpanName {
+			spanName = SanitizePath(spanName, opts.PathSegmentPatterns)
+		}
+
+		// WebSocket upgrades get a server span covering only the handshake;
+		// the connection lifetime can be traced with StartWebSocketSpan
+		startOpts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer)}
+		webSocket := isWebSocketUpgrade(c.Request)
+		if webSocket {
+			startOpts = append(startOpts, trace.WithAttributes(attribute.String("http.upgrade", "websocket")))
+		}
 
 		// Start a new span
-		ctx, span := tracer.Start(ctx, spanName)
-		defer span.End()
-
-		// Add default HTTP attributes
-		span.SetAttributes(
-			attribute.String("http.method", c.Request.Method),
-			attribute.String("http.url", c.Request.URL.String()),
-			attribute.String("http.host", c.Request.Host),
-			attribute.String("http.user_agent", c.Request.UserAgent()),
-			attribute.String("http.scheme", getScheme(c.Request)),
-			attribute.String("http.target", c.Request.URL.Path),
-		)
-
-		// Add route parameters as attributes if available
-		if len(c.Params) > 0 {
-			for k, v := range c.Params {
-				span.SetAttributes(attribute.String("http.route.param."+k, v))
+		startOpts = append(startOpts, trace.WithTimestamp(opts.Clock.Now()))
+		ctx, span := tracer.Start(ctx, spanName, startOpts...)
+		var recorder *TracingResponseWriter
+		handshakeEnded := false
+		defer func() {
+			// The handshake span already ended when the connection switched protocols
+			if handshakeEnded {
+				return
+			}
+			// Push the written response to the client first so the span covers its delivery
+			if opts.SpanEndTiming == SpanEndAfterFlush && recorder != nil && recorder.WroteHeader() {
+				recorder.Flush()
+			}
+			span.End(trace.WithTimestamp(opts.Clock.Now()))
+		}()
+
+		// Add default HTTP attributes, the route template (omitted when no template matched),
+		// route parameters and request encoding, unless deselected
+		defaultAttrs := httpRequestAttributes(c.Request, opts.SemConvVersion, opts.RedactQueryParams)
+		if route != "" {
+			defaultAttrs = append(defaultAttrs, attribute.String("http.route", route))
+		}
+		for k, v := range c.Params {
+			if opts.recordRouteParam(k) {
+				defaultAttrs = append(defaultAttrs, attribute.String(opts.RouteParamPrefix+k, v))
+			}
+		}
+		if encoding := c.Request.Header.Get("Content-Encoding"); encoding != "" {
+			defaultAttrs = append(defaultAttrs, attribute.String("http.request.encoding", encoding))
+		}
+		span.SetAttributes(opts.selectDefaultAttributes(defaultAttrs)...)
+
+		// Add allowlisted request headers as attributes
+		if len(opts.CaptureRequestHeaders) > 0 {
+			span.SetAttributes(headerAttributes(c.Request, opts.CaptureRequestHeaders)...)
+		}
+
+		// Add query parameters as attributes, redacting sensitive values
+		if opts.CaptureQueryParams {
+			span.SetAttributes(queryAttributes(c.Request, opts.RedactQueryParams)...)
+		}
+
+		// Copy selected baggage members from the incoming context
+		if len(opts.BaggageToAttributes) > 0 {
+			span.SetAttributes(baggageAttributes(ctx, opts.BaggageToAttributes)...)
+		}
+
+		// Record the request body, leaving it readable for the handler
+		if opts.CaptureRequestBody != nil {
+			limit := opts.CaptureRequestBody.MaxBodyBytes
+			if limit <= 0 {
+				limit = DefaultRequestBodyCaptureLimit
+			}
+			contentTypes := opts.CaptureRequestBody.ContentTypes
+			if len(contentTypes) == 0 {
+				contentTypes = []string{"application/json"}
+			}
+			if body, truncated, ok := peekRequestBody(c.Request, limit, contentTypes); ok {
+				span.AddEvent("request.body", trace.WithAttributes(
+					attribute.String("http.request.body", string(body)),
+					attribute.Bool("http.request.body.truncated", truncated),
+				))
 			}
 		}
 
@@ -67,27 +173,122 @@ func (i *Integration) Middleware(options ...MiddlewareOptions) vayu.HandlerFunc
 		}
 
 		// Store the tracer name in the context
-		ctx = context.WithValue(ctx, tracerNameKey, tracerNameValue)
+		ctx = i.provider.withInstrumentation(ctx)
+
+		// Let TraceHandler report the matched route
+		matched := &matchedRoute{}
+		ctx = context.WithValue(ctx, matchedRouteKey, matched)
+
+		// Keep the server span reachable after handler spans replace it as the current span
+		ctx = context.WithValue(ctx, serverSpanKey, span)
 
 		// Store the span in the request context
 		c.Request = c.Request.WithContext(ctx)
 
-		// Call the next handler
+		// Expose the trace ID before the handler writes the response
+		if opts.InjectTraceHeader && span.SpanContext().HasTraceID() {
+			c.Writer.Header().Set(TraceIDHeader, span.SpanContext().TraceID().String())
+		}
+
+		// Report whether the request's trace is sampled
+		if opts.ExposeSamplingHeader {
+			c.Writer.Header().Set(TraceSampledHeader, strconv.FormatBool(span.SpanContext().IsSampled()))
+		}
+
+		// Wrap the response writer to capture the status code, and the body if it is inspected for errors
+		recorder = NewTracingResponseWriter(c.Writer)
+		if opts.ResponseErrorDetector != nil {
+			recorder.bodyLimit = opts.ResponseBodyCaptureLimit
+		}
+		c.Writer = recorder
+
+		// End the handshake span once the 101 is sent or the connection is hijacked,
+		// rather than when the handler returns after serving the connection
+		if webSocket {
+			recorder.onSwitchingProtocols = func() {
+				attrs := httpStatusAttributes(http.StatusSwitchingProtocols, opts.SemConvVersion)
+				attrs = append(attrs, attribute.String("http.status_class", statusClass(http.StatusSwitchingProtocols)))
+				span.SetAttributes(opts.selectDefaultAttributes(attrs)...)
+				span.End(trace.WithTimestamp(opts.Clock.Now()))
+				handshakeEnded = true
+			}
+		}
+
+		// Call the next handler, timing it separately from the middleware's own overhead
+		handlerStart := opts.Clock.Now()
+		if opts.EmitLifecycleEvents {
+			span.AddEvent("request.start", trace.WithTimestamp(handlerStart), trace.WithAttributes(
+				attribute.String("http.method", c.Request.Method),
+			))
+		}
 		next()
+		handlerDuration := opts.Clock.Now().Sub(handlerStart)
 
-		// Get the response writer to extract status code
-		// Note: This assumes Vayu's response writer tracks status code internally
-		// If not, we'll need to adapt this approach
-		responseStatus := 200 // Default to 200 if we can't determine
+		span.SetAttributes(opts.selectDefaultAttributes([]attribute.KeyValue{
+			attribute.Float64("http.server.duration_ms", float64(handlerDuration)/float64(time.Millisecond)),
+		})...)
+		StoreRequestDuration(c, handlerDuration)
 
-		// Add response status code attribute
-		span.SetAttributes(attribute.Int("http.status_code", responseStatus))
+		responseStatus := recorder.StatusCode()
+		if opts.EmitLifecycleEvents {
+			span.AddEvent("request.end", trace.WithTimestamp(handlerStart.Add(handlerDuration)), trace.WithAttributes(
+				attribute.String("http.method", c.Request.Method),
+				attribute.Int("http.status_code", responseStatus),
+			))
+		}
 
-		// Mark span as error if status code is 5xx
-		if responseStatus >= 500 {
+		// Add response status code, status class, encoding, content type and gRPC status attributes
+		responseAttrs := httpStatusAttributes(responseStatus, opts.SemConvVersion)
+		responseAttrs = append(responseAttrs, attribute.String("http.status_class", statusClass(responseStatus)))
+		if encoding := recorder.Header().Get("Content-Encoding"); encoding != "" {
+			responseAttrs = append(responseAttrs, attribute.String("http.response.encoding", encoding))
+		}
+		if opts.CaptureResponseContentType {
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "" {
+				responseAttrs = append(responseAttrs, attribute.String("http.response.content_type", contentType))
+			}
+		}
+		if route == "" && matched.template != "" {
+			// The path matched no registered template, but TraceHandler knows the route that ran
+			responseAttrs = append(responseAttrs, attribute.String("http.route", matched.template))
+		}
+		if opts.GRPCStatusMapper != nil {
+			responseAttrs = append(responseAttrs, attribute.Int("rpc.grpc.status_code", opts.GRPCStatusMapper(responseStatus)))
+		}
+		span.SetAttributes(opts.selectDefaultAttributes(responseAttrs)...)
+
+		// Collapse the names of requests that matched no route
+		if opts.CollapseUnmatchedRoutes && responseStatus == http.StatusNotFound && route == "" && matched.template == "" && len(c.Params) == 0 {
+			span.SetName(fmt.Sprintf("HTTP %s [unmatched]", c.Request.Method))
+		}
+
+		// A client that disconnected is not a server error, so leave the status unset
+		if c.Request.Context().Err() == context.Canceled {
+			span.SetAttributes(attribute.Bool("http.client_disconnected", true))
+			span.SetStatus(codes.Unset, "")
+			return
+		}
+
+		// Mark span as error if the predicate matches the status code
+		if opts.ErrorStatusPredicate(responseStatus) {
 			span.SetAttributes(attribute.Bool("error", true))
 			span.SetStatus(codes.Error, fmt.Sprintf("Error: HTTP %d", responseStatus))
 		}
+
+		// Mark span as error if the response body carries an error envelope
+		if opts.ResponseErrorDetector != nil && opts.ResponseErrorDetector(responseStatus, recorder.capturedBody()) {
+			span.SetAttributes(attribute.Bool("error", true))
+			span.SetStatus(codes.Error, "Error: response body reported an error")
+		}
+
+		// Mark span as error if the handler reported an error, regardless of status code
+		if opts.ErrorExtractor != nil {
+			if err := opts.ErrorExtractor(c); err != nil {
+				span.RecordError(err)
+				span.SetAttributes(attribute.Bool("error", true))
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
 	}
 }
 
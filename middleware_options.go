@@ -2,11 +2,103 @@ package vayuotel
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kaushiksamanta/vayu"
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// DefaultResponseBodyCaptureLimit is the number of response body bytes buffered for
+// ResponseErrorDetector when ResponseBodyCaptureLimit is not set
+const DefaultResponseBodyCaptureLimit = 4096
+
+// Clock provides the current time to the middleware, so tests can control measured durations
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock backed by time.Now
+type systemClock struct{}
+
+// Now implements Clock
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Span end timings for MiddlewareOptions.SpanEndTiming
+const (
+	// SpanEndAfterNext ends the server span once the handler chain has returned
+	SpanEndAfterNext = "after_next"
+
+	// SpanEndAfterFlush flushes the response to the client before ending the server span,
+	// so the span also covers sending buffered response data
+	SpanEndAfterFlush = "after_flush"
+)
+
+// DefaultRouteParamPrefix is the attribute key prefix for route params when RouteParamPrefix is not set
+const DefaultRouteParamPrefix = "http.route.param."
+
+// DefaultRequestBodyCaptureLimit is the number of request body bytes recorded when
+// RequestBodyCapture.MaxBodyBytes is not set
+const DefaultRequestBodyCaptureLimit = 4096
+
+// RequestBodyCapture configures which request bodies the middleware records
+type RequestBodyCapture struct {
+	// MaxBodyBytes caps the recorded body; longer bodies are truncated
+	// If zero, DefaultRequestBodyCaptureLimit is used
+	MaxBodyBytes int
+
+	// ContentTypes lists the media types whose bodies are recorded
+	// If empty, only "application/json" bodies are recorded
+	ContentTypes []string
+}
+
+// PathSegmentPattern replaces path segments matching Pattern with Replacement when sanitizing span names
+type PathSegmentPattern struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// DefaultPathSegmentPatterns collapse numeric IDs to "{id}" and UUIDs to "{uuid}"
+func DefaultPathSegmentPatterns() []PathSegmentPattern {
+	return []PathSegmentPattern{
+		{Pattern: regexp.MustCompile(`^[0-9]+$`), Replacement: "{id}"},
+		{Pattern: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`), Replacement: "{uuid}"},
+	}
+}
+
+// SanitizePath replaces each path segment matching one of the patterns with its replacement
+// The first matching pattern wins
+func SanitizePath(path string, patterns []PathSegmentPattern) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		for _, p := range patterns {
+			if p.Pattern.MatchString(segment) {
+				segments[i] = p.Replacement
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// TraceIDHeader is the response header that carries the trace ID when InjectTraceHeader is enabled
+const TraceIDHeader = "X-Trace-Id"
+
+// TraceSampledHeader is the response header that reports the sampling decision when
+// ExposeSamplingHeader is enabled
+const TraceSampledHeader = "X-Trace-Sampled"
+
+// DebugTraceHeader is the request header that forces a request to be sampled when
+// ForceSampleOnDebugHeader is enabled
+const DebugTraceHeader = "X-Debug-Trace"
+
 // MiddlewareOptions contains configuration options for the tracing middleware
 type MiddlewareOptions struct {
 	// SpanNameFormatter is a function that formats the span name for a request
@@ -14,8 +106,133 @@ type MiddlewareOptions struct {
 	SpanNameFormatter func(c *vayu.Context) string
 
 	// CustomAttributes is a function that adds custom attributes to the span
-	// This is called in addition to the default HTTP attributes
+	// It is called for every request, in addition to the default HTTP attributes
+	// Use WithHeaderAttribute and CombineAttributeFuncs to build it from request headers
 	CustomAttributes func(c *vayu.Context) []attribute.KeyValue
+
+	// ErrorStatusPredicate reports whether a response status code should mark the span as an error
+	// If nil, status codes >= 500 are treated as errors
+	ErrorStatusPredicate func(statusCode int) bool
+
+	// ErrorExtractor returns the error a handler reported (e.g. stored on the context), if any
+	// A non-nil error is recorded on the span and marks it as an error even for 2xx responses
+	ErrorExtractor func(c *vayu.Context) error
+
+	// ResponseErrorDetector reports whether a response is an error based on its status code and body
+	// (e.g. a 200 carrying an {"error": "..."} envelope). Setting it opts in to buffering the body
+	ResponseErrorDetector func(statusCode int, body []byte) bool
+
+	// ResponseBodyCaptureLimit caps how many response body bytes are buffered for ResponseErrorDetector
+	// If zero, DefaultResponseBodyCaptureLimit is used
+	ResponseBodyCaptureLimit int
+
+	// RouteParamPrefix is prepended to route param names to form attribute keys
+	// If empty, DefaultRouteParamPrefix is used (e.g. "http.route.param.id")
+	RouteParamPrefix string
+
+	// RouteParamAllowlist, if set, limits the route params recorded as attributes to these names
+	RouteParamAllowlist []string
+
+	// RouteParamDenylist lists route params never recorded as attributes (e.g. high-cardinality IDs)
+	RouteParamDenylist []string
+
+	// CaptureRequestHeaders is an allowlist of request headers to record as span attributes
+	// Each present header is added as "http.request.header.<name>"; all other headers are skipped
+	CaptureRequestHeaders []string
+
+	// CaptureQueryParams adds each query parameter as an "http.query.<key>" attribute
+	// Multi-valued parameters are joined with commas
+	CaptureQueryParams bool
+
+	// RedactQueryParams lists query parameter keys (case-insensitive) whose values are replaced
+	// with "[REDACTED]" in the URL attributes and, when CaptureQueryParams is enabled, in the
+	// "http.query.<key>" attributes
+	RedactQueryParams []string
+
+	// BaggageToAttributes lists baggage keys (e.g. "tenant") copied from the incoming request's
+	// baggage onto the server span as "baggage.<key>" attributes
+	BaggageToAttributes []string
+
+	// CaptureResponseContentType records the response's Content-Type header, if the handler
+	// set one, as the "http.response.content_type" attribute
+	CaptureResponseContentType bool
+
+	// GRPCStatusMapper, if set, maps the response status to a gRPC status code recorded as
+	// "rpc.grpc.status_code" alongside the HTTP status (e.g. DefaultGRPCStatusMapper for gateways)
+	GRPCStatusMapper func(httpStatus int) int
+
+	// CaptureRequestBody records small request bodies as a "request.body" span event
+	// (e.g. for debugging webhooks). If nil, bodies are not read
+	CaptureRequestBody *RequestBodyCapture
+
+	// SpanEndTiming selects when the server span ends: SpanEndAfterNext (default) or SpanEndAfterFlush
+	SpanEndTiming string
+
+	// SemConvVersion selects the attribute naming for default HTTP attributes:
+	// SemConvLegacy (default), SemConvStable, or SemConvBoth during a migration
+	SemConvVersion string
+
+	// SanitizeSpanName collapses dynamic path segments in the span name to cap cardinality
+	// (e.g. "HTTP GET /orders/42" becomes "HTTP GET /orders/{id}")
+	SanitizeSpanName bool
+
+	// PathSegmentPatterns are the patterns applied when SanitizeSpanName is set
+	// If nil, DefaultPathSegmentPatterns is used
+	PathSegmentPatterns []PathSegmentPattern
+
+	// InjectTraceHeader sets the X-Trace-Id response header to the request's trace ID
+	// so users can quote it in support tickets
+	InjectTraceHeader bool
+
+	// ExposeSamplingHeader sets the X-Trace-Sampled response header to "true" or "false",
+	// to help debug why a trace did not reach the backend
+	ExposeSamplingHeader bool
+
+	// ForceSampleOnDebugHeader samples every request carrying "X-Debug-Trace: 1", regardless
+	// of the configured sampler, for on-demand debugging
+	ForceSampleOnDebugHeader bool
+
+	// RoutePriority computes a priority for each request before its span starts; PrioritySampler
+	// reads it to always sample important requests (e.g. routes tagged as critical)
+	RoutePriority func(c *vayu.Context) int
+
+	// DisableDefaultAttributes drops the default http.* attributes (including route params),
+	// leaving only header, query and custom attributes
+	DisableDefaultAttributes bool
+
+	// AttributeSelector reports whether a default attribute (e.g. "http.user_agent" or
+	// "http.route.param.id") is recorded. If nil, all default attributes are recorded
+	AttributeSelector func(name string) bool
+
+	// Clock times the span and the handler duration; if nil, the system clock is used
+	Clock Clock
+
+	// EmitLifecycleEvents adds "request.start" and "request.end" events to the server span
+	// around the handler, for backends that visualize events within a span
+	EmitLifecycleEvents bool
+
+	// CollapseUnmatchedRoutes names 404 responses that matched no route "HTTP {method} [unmatched]"
+	// so every unknown URL does not create a distinct span name. A request counts as matched
+	// if it has route params or its handler was wrapped with TraceHandler (or the Traced* helpers)
+	CollapseUnmatchedRoutes bool
+}
+
+// selectDefaultAttributes filters default attributes by DisableDefaultAttributes and AttributeSelector
+func (o MiddlewareOptions) selectDefaultAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if o.DisableDefaultAttributes {
+		return nil
+	}
+	if o.AttributeSelector == nil {
+		return attrs
+	}
+
+	selected := attrs[:0]
+	for _, attr := range attrs {
+		if o.AttributeSelector(string(attr.Key)) {
+			selected = append(selected, attr)
+		}
+	}
+	return selected
 }
 
 // DefaultMiddlewareOptions returns the default options for the tracing middleware
@@ -24,10 +241,43 @@ func DefaultMiddlewareOptions() MiddlewareOptions {
 		SpanNameFormatter: func(c *vayu.Context) string {
 			return fmt.Sprintf("HTTP %s %s", c.Request.Method, c.Request.URL.Path)
 		},
-		CustomAttributes: nil,
+		CustomAttributes:     nil,
+		ErrorStatusPredicate: defaultErrorStatusPredicate,
+		SemConvVersion:       SemConvLegacy,
+	}
+}
+
+// WithHeaderAttribute returns a CustomAttributes function that records the request header
+// headerName as the attribute attrKey (e.g. "X-Tenant-ID" as "tenant.id"); absent headers are skipped
+func WithHeaderAttribute(headerName, attrKey string) func(c *vayu.Context) []attribute.KeyValue {
+	return func(c *vayu.Context) []attribute.KeyValue {
+		value := c.Request.Header.Get(headerName)
+		if value == "" {
+			return nil
+		}
+		return []attribute.KeyValue{attribute.String(attrKey, value)}
 	}
 }
 
+// CombineAttributeFuncs returns a CustomAttributes function that concatenates the attributes
+// of all fns, so independent sources (auth, tenant, feature flags) can be composed. Nil fns are skipped
+func CombineAttributeFuncs(fns ...func(c *vayu.Context) []attribute.KeyValue) func(c *vayu.Context) []attribute.KeyValue {
+	return func(c *vayu.Context) []attribute.KeyValue {
+		var attrs []attribute.KeyValue
+		for _, fn := range fns {
+			if fn != nil {
+				attrs = append(attrs, fn(c)...)
+			}
+		}
+		return attrs
+	}
+}
+
+// defaultErrorStatusPredicate treats server errors (5xx) as span errors
+func defaultErrorStatusPredicate(statusCode int) bool {
+	return statusCode >= 500
+}
+
 // TraceAllRequests is a convenience function that sets up the integration and returns a middleware
 func TraceAllRequests(app *vayu.App, config Config) (*Integration, error) {
 	// Set up integration options
@@ -46,3 +296,11 @@ func TraceAllRequests(app *vayu.App, config Config) (*Integration, error) {
 
 	return integration, nil
 }
+
+// recordRouteParam reports whether the route param name passes the allow and deny lists
+func (o MiddlewareOptions) recordRouteParam(name string) bool {
+	if len(o.RouteParamAllowlist) > 0 && !slices.Contains(o.RouteParamAllowlist, name) {
+		return false
+	}
+	return !slices.Contains(o.RouteParamDenylist, name)
+}
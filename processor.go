@@ -0,0 +1,87 @@
+package vayuotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// slowSpanProcessor passes sampled spans, and unsampled spans that took at least threshold
+// to complete, to the exporting processor. Slow spans thus share its queue and exporter, so
+// ending them never waits on an export and the exporter is never called concurrently
+type slowSpanProcessor struct {
+	next      sdktrace.SpanProcessor
+	threshold time.Duration
+}
+
+// newSlowSpanProcessor creates a processor that forwards slow unsampled spans to next for export
+func newSlowSpanProcessor(next sdktrace.SpanProcessor, threshold time.Duration) *slowSpanProcessor {
+	return &slowSpanProcessor{
+		next:      next,
+		threshold: threshold,
+	}
+}
+
+// OnStart implements sdktrace.SpanProcessor
+func (p *slowSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd implements sdktrace.SpanProcessor
+func (p *slowSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.next.OnEnd(s)
+		return
+	}
+
+	if s.EndTime().Sub(s.StartTime()) < p.threshold {
+		return
+	}
+
+	// Exporting processors skip unsampled spans, so present the slow span as sampled
+	p.next.OnEnd(sampledSpan{ReadOnlySpan: s})
+}
+
+// Shutdown implements sdktrace.SpanProcessor
+func (p *slowSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush implements sdktrace.SpanProcessor
+func (p *slowSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// sampledSpan is a read-only span whose span context is marked as sampled
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+// SpanContext implements sdktrace.ReadOnlySpan
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+// recordingSampler turns the base sampler's drop decisions into record-only decisions
+// so unsampled spans still reach span processors and their duration can be inspected
+type recordingSampler struct {
+	base sdktrace.Sampler
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s recordingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.base.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+// Description implements sdktrace.Sampler
+func (s recordingSampler) Description() string {
+	return fmt.Sprintf("RecordingSampler{%s}", s.base.Description())
+}
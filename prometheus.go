@@ -0,0 +1,101 @@
+package vayuotel
+
+import (
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// prometheusDurationName is the name the Prometheus exporter gives the request duration histogram
+const prometheusDurationName = "http_server_duration_milliseconds"
+
+// PrometheusHandler returns an http.Handler serving the collected metrics in the Prometheus
+// text exposition format. Mount it at /metrics; it responds 404 unless the
+// "prometheus" metrics exporter is configured. Scrapers accepting OpenMetrics receive
+// that format instead, with trace exemplars on the request duration histogram
+func (i *Integration) PrometheusHandler() http.Handler {
+	if i.provider == nil || i.provider.metricsRegistry == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "prometheus metrics exporter not configured", http.StatusNotFound)
+		})
+	}
+
+	return promhttp.HandlerFor(&exemplarGatherer{
+		Gatherer:  i.provider.metricsRegistry,
+		exemplars: i.provider.exemplars,
+	}, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// exemplarGatherer attaches the reservoir's exemplars to the buckets of the request duration
+// histogram. The pinned exporter does not emit exemplars, and only OpenMetrics encodes them
+type exemplarGatherer struct {
+	prometheus.Gatherer
+	exemplars *exemplarReservoir
+}
+
+// Gather implements prometheus.Gatherer
+func (g *exemplarGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.Gatherer.Gather()
+	if g.exemplars == nil {
+		return families, err
+	}
+
+	for _, family := range families {
+		if family.GetName() != prometheusDurationName || family.GetType() != dto.MetricType_HISTOGRAM {
+			continue
+		}
+		for _, m := range family.Metric {
+			exemplars := g.exemplars.lookup(durationAttributes(durationLabels(m.GetLabel())))
+			if len(exemplars) == 0 {
+				continue
+			}
+			lower := math.Inf(-1)
+			for _, bucket := range m.GetHistogram().GetBucket() {
+				if exemplar, ok := bucketExemplar(exemplars, lower, bucket.GetUpperBound()); ok {
+					bucket.Exemplar = exemplarToProto(exemplar)
+				}
+				lower = bucket.GetUpperBound()
+			}
+		}
+	}
+	return families, err
+}
+
+// durationLabels reads the request duration attributes back from exported labels
+func durationLabels(labels []*dto.LabelPair) (method, route string, status int) {
+	for _, label := range labels {
+		switch label.GetName() {
+		case "http_method":
+			method = label.GetValue()
+		case "http_route":
+			route = label.GetValue()
+		case "http_status_code":
+			status, _ = strconv.Atoi(label.GetValue())
+		}
+	}
+	return method, route, status
+}
+
+// exemplarToProto converts an exemplar to its Prometheus representation
+func exemplarToProto(exemplar metricdata.Exemplar[float64]) *dto.Exemplar {
+	return &dto.Exemplar{
+		Label: []*dto.LabelPair{
+			{Name: stringPtr("trace_id"), Value: stringPtr(hex.EncodeToString(exemplar.TraceID))},
+			{Name: stringPtr("span_id"), Value: stringPtr(hex.EncodeToString(exemplar.SpanID))},
+		},
+		Value:     &exemplar.Value,
+		Timestamp: timestamppb.New(exemplar.Time),
+	}
+}
+
+// stringPtr returns a pointer to s
+func stringPtr(s string) *string {
+	return &s
+}
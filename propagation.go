@@ -0,0 +1,179 @@
+package vayuotel
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextFromCarrier extracts a remote parent span context from a serialized carrier
+// (e.g. message headers containing "traceparent") using the provider's propagators,
+// so a consumer can continue the producer's trace
+func (p *Provider) ContextFromCarrier(ctx context.Context, carrier map[string]string) context.Context {
+	if carrier == nil {
+		return ctx
+	}
+	return p.textMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// InjectToCarrier writes the trace context of ctx into the carrier using the provider's
+// propagators, so it can be serialized alongside a message for a consumer
+func (p *Provider) InjectToCarrier(ctx context.Context, carrier map[string]string) {
+	if carrier == nil {
+		return
+	}
+	p.textMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+// InjectHeaders writes the trace context of ctx through setter using the provider's propagators,
+// so it can be attached to message headers (Kafka, NATS, RabbitMQ) of any representation
+func (p *Provider) InjectHeaders(ctx context.Context, setter func(key, value string)) {
+	if setter == nil {
+		return
+	}
+	p.textMapPropagator().Inject(ctx, headerFuncCarrier{set: setter})
+}
+
+// ExtractHeaders reads a remote parent span context through getter using the provider's
+// propagators; keys lists the header keys present on the message
+func (p *Provider) ExtractHeaders(ctx context.Context, getter func(key string) (string, bool), keys []string) context.Context {
+	if getter == nil {
+		return ctx
+	}
+	return p.textMapPropagator().Extract(ctx, headerFuncCarrier{get: getter, keys: keys})
+}
+
+// MapCarrier adapts a map of message headers to InjectHeaders and ExtractHeaders
+// via its Set and Get method values
+type MapCarrier map[string]string
+
+// Set stores a header value
+func (m MapCarrier) Set(key, value string) {
+	m[key] = value
+}
+
+// Get returns a header value and whether it was present
+func (m MapCarrier) Get(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// Keys returns the header keys
+func (m MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// headerFuncCarrier adapts header accessor functions to propagation.TextMapCarrier
+type headerFuncCarrier struct {
+	get  func(key string) (string, bool)
+	set  func(key, value string)
+	keys []string
+}
+
+// Get implements propagation.TextMapCarrier
+func (c headerFuncCarrier) Get(key string) string {
+	if c.get == nil {
+		return ""
+	}
+	value, _ := c.get(key)
+	return value
+}
+
+// Set implements propagation.TextMapCarrier
+func (c headerFuncCarrier) Set(key, value string) {
+	if c.set != nil {
+		c.set(key, value)
+	}
+}
+
+// Keys implements propagation.TextMapCarrier
+func (c headerFuncCarrier) Keys() []string {
+	return c.keys
+}
+
+// cloudTraceContextHeader is the Google Cloud trace header, formatted as "TRACE_ID/SPAN_ID;o=OPTIONS"
+// where SPAN_ID is a decimal number and o=1 marks the trace as sampled
+const cloudTraceContextHeader = "x-cloud-trace-context"
+
+// CloudTraceContext propagates trace context in the Google Cloud X-Cloud-Trace-Context header
+// On extraction it acts as a fallback: a span context already extracted by an earlier
+// propagator (e.g. W3C traceparent) is kept
+type CloudTraceContext struct{}
+
+var _ propagation.TextMapPropagator = CloudTraceContext{}
+
+// Inject implements propagation.TextMapPropagator
+func (CloudTraceContext) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	sampled := 0
+	if sc.IsSampled() {
+		sampled = 1
+	}
+	spanID := sc.SpanID()
+	carrier.Set(cloudTraceContextHeader, fmt.Sprintf("%s/%d;o=%d", sc.TraceID(), binary.BigEndian.Uint64(spanID[:]), sampled))
+}
+
+// Extract implements propagation.TextMapPropagator
+func (CloudTraceContext) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	sc, ok := parseCloudTraceContext(carrier.Get(cloudTraceContextHeader))
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields implements propagation.TextMapPropagator
+func (CloudTraceContext) Fields() []string {
+	return []string{cloudTraceContextHeader}
+}
+
+// parseCloudTraceContext parses an X-Cloud-Trace-Context header value
+func parseCloudTraceContext(value string) (trace.SpanContext, bool) {
+	traceIDPart, rest, found := strings.Cut(value, "/")
+	if !found {
+		return trace.SpanContext{}, false
+	}
+
+	traceID, err := trace.TraceIDFromHex(traceIDPart)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	spanIDPart, options, _ := strings.Cut(rest, ";")
+	spanIDValue, err := strconv.ParseUint(spanIDPart, 10, 64)
+	if err != nil || spanIDValue == 0 {
+		return trace.SpanContext{}, false
+	}
+
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], spanIDValue)
+
+	var flags trace.TraceFlags
+	if options == "o=1" {
+		flags = trace.FlagsSampled
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}), true
+}
@@ -0,0 +1,119 @@
+package vayuotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// redactingExporter replaces the values of sensitive span attributes before passing spans on
+// Ended spans are read-only, so redaction happens at export time rather than in a span processor
+type redactingExporter struct {
+	next sdktrace.SpanExporter
+	keys map[attribute.Key]struct{}
+}
+
+// NewRedactingExporter wraps next so the values of the given attribute keys are exported as "[REDACTED]"
+func NewRedactingExporter(keys []string, next sdktrace.SpanExporter) sdktrace.SpanExporter {
+	set := make(map[attribute.Key]struct{}, len(keys))
+	for _, key := range keys {
+		set[attribute.Key(key)] = struct{}{}
+	}
+	return &redactingExporter{next: next, keys: set}
+}
+
+// ExportSpans implements sdktrace.SpanExporter
+func (e *redactingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		redacted[i] = e.redact(s)
+	}
+	return e.next.ExportSpans(ctx, redacted)
+}
+
+// Shutdown implements sdktrace.SpanExporter
+func (e *redactingExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx)
+}
+
+// redact returns s with sensitive attribute values of the span, its events and its links
+// replaced, or s itself if none matched
+func (e *redactingExporter) redact(s sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	attributes, changed := e.redactAttributes(s.Attributes())
+
+	// Copy the events and links before the first change, leaving the span's own slices untouched
+	events, copied := s.Events(), false
+	for i, event := range events {
+		redacted, ok := e.redactAttributes(event.Attributes)
+		if !ok {
+			continue
+		}
+		if !copied {
+			events, copied = append([]sdktrace.Event(nil), events...), true
+		}
+		events[i].Attributes = redacted
+	}
+	changed = changed || copied
+
+	links, copied := s.Links(), false
+	for i, link := range links {
+		redacted, ok := e.redactAttributes(link.Attributes)
+		if !ok {
+			continue
+		}
+		if !copied {
+			links, copied = append([]sdktrace.Link(nil), links...), true
+		}
+		links[i].Attributes = redacted
+	}
+	changed = changed || copied
+
+	if !changed {
+		return s
+	}
+	return redactedSpan{ReadOnlySpan: s, attributes: attributes, events: events, links: links}
+}
+
+// redactAttributes returns a copy of attrs with sensitive values replaced, and whether any matched
+func (e *redactingExporter) redactAttributes(attrs []attribute.KeyValue) ([]attribute.KeyValue, bool) {
+	var out []attribute.KeyValue
+	for i, attr := range attrs {
+		if _, ok := e.keys[attr.Key]; !ok {
+			continue
+		}
+		if out == nil {
+			out = make([]attribute.KeyValue, len(attrs))
+			copy(out, attrs)
+		}
+		out[i] = attribute.String(string(attr.Key), redactedValue)
+	}
+
+	if out == nil {
+		return attrs, false
+	}
+	return out, true
+}
+
+// redactedSpan overrides the attributes, events and links of a read-only span
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	attributes []attribute.KeyValue
+	events     []sdktrace.Event
+	links      []sdktrace.Link
+}
+
+// Attributes implements sdktrace.ReadOnlySpan
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	return s.attributes
+}
+
+// Events implements sdktrace.ReadOnlySpan
+func (s redactedSpan) Events() []sdktrace.Event {
+	return s.events
+}
+
+// Links implements sdktrace.ReadOnlySpan
+func (s redactedSpan) Links() []sdktrace.Link {
+	return s.links
+}
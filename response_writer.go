@@ -0,0 +1,122 @@
+package vayuotel
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// TracingResponseWriter wraps an http.ResponseWriter to record the response status code and
+// the number of body bytes written. Flush, Hijack and Push are passed through when the
+// wrapped writer supports them
+type TracingResponseWriter struct {
+	http.ResponseWriter
+
+	status       int
+	wroteHeader  bool
+	bytesWritten int64
+
+	// bodyLimit is the number of body bytes to capture into body (zero disables capture)
+	bodyLimit int
+	body      []byte
+
+	// onSwitchingProtocols is called once when a 101 status is written or the connection is hijacked
+	onSwitchingProtocols func()
+}
+
+// NewTracingResponseWriter wraps w
+func NewTracingResponseWriter(w http.ResponseWriter) *TracingResponseWriter {
+	return &TracingResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status code before delegating to the wrapped writer
+func (w *TracingResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.status = statusCode
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+	if w.status == http.StatusSwitchingProtocols {
+		w.switchedProtocols()
+	}
+}
+
+// Write records an implicit 200 status if no header was written yet and counts the bytes written
+func (w *TracingResponseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.status = http.StatusOK
+		w.wroteHeader = true
+	}
+	if remaining := w.bodyLimit - len(w.body); remaining > 0 {
+		w.body = append(w.body, data[:min(remaining, len(data))]...)
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// Hijack lets the handler take over the connection (e.g. for WebSocket upgrades)
+func (w *TracingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("underlying response writer does not support hijacking")
+	}
+	if !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	w.switchedProtocols()
+	return hijacker.Hijack()
+}
+
+// switchedProtocols runs the onSwitchingProtocols callback the first time it is called
+func (w *TracingResponseWriter) switchedProtocols() {
+	if callback := w.onSwitchingProtocols; callback != nil {
+		w.onSwitchingProtocols = nil
+		callback()
+	}
+}
+
+// Flush sends any buffered data to the client if the wrapped writer supports it
+func (w *TracingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Push initiates an HTTP/2 server push, returning http.ErrNotSupported if the wrapped writer cannot
+func (w *TracingResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the wrapped writer so http.ResponseController can reach it
+func (w *TracingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// StatusCode returns the captured status code, defaulting to 200 if nothing was written
+func (w *TracingResponseWriter) StatusCode() int {
+	if !w.wroteHeader {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// BytesWritten returns the number of response body bytes written
+func (w *TracingResponseWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+// WroteHeader reports whether the status code has been sent (explicitly or by the first write)
+func (w *TracingResponseWriter) WroteHeader() bool {
+	return w.wroteHeader
+}
+
+// capturedBody returns the captured prefix of the response body
+func (w *TracingResponseWriter) capturedBody() []byte {
+	return w.body
+}
@@ -0,0 +1,108 @@
+package vayuotel
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/kaushiksamanta/vayu"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// routeRegistry holds the route templates known to the integration
+type routeRegistry struct {
+	mu        sync.RWMutex
+	templates []string
+}
+
+// add registers templates that are not registered yet
+func (r *routeRegistry) add(templates ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, template := range templates {
+		if !slices.Contains(r.templates, template) {
+			r.templates = append(r.templates, template)
+		}
+	}
+}
+
+// match returns the registered template matching path, or "" if none does
+func (r *routeRegistry) match(path string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return matchRoute(path, r.templates)
+}
+
+// RegisterRoutes declares the application's route templates (e.g. "/users/:id"), which the
+// middlewares report as http.route and RouteSampler matches on. Routes wrapped with
+// TraceHandler or registered with the Traced* helpers are registered automatically.
+// Requests matching no registered template get no route, so raw paths never become routes
+func (i *Integration) RegisterRoutes(templates ...string) {
+	i.routes.add(templates...)
+}
+
+// TraceHandler wraps handler so its body runs in a child span named after the method and route,
+// separate from the middleware's server span
+func (i *Integration) TraceHandler(route string, handler vayu.HandlerFunc) vayu.HandlerFunc {
+	if i.provider.Config.Disabled {
+		return handler
+	}
+
+	tracer := i.provider.tracer()
+	i.routes.add(route)
+
+	return func(c *vayu.Context, next vayu.NextFunc) {
+		ctx, span := tracer.Start(c.Request.Context(), fmt.Sprintf("%s %s", c.Request.Method, route))
+		defer span.End()
+
+		span.SetAttributes(attribute.String("http.route", route))
+
+		// Tell the middleware the request matched a route
+		if matched, ok := c.Request.Context().Value(matchedRouteKey).(*matchedRoute); ok {
+			matched.template = route
+		}
+
+		// Make the handler span the parent of spans started by the handler
+		c.Request = c.Request.WithContext(ctx)
+
+		handler(c, next)
+	}
+}
+
+// RouteGroup is anything routes can share middleware through, such as a Vayu route group or app
+type RouteGroup interface {
+	Use(handler vayu.HandlerFunc)
+}
+
+// TraceGroup registers middleware on group that adds attrs (e.g. "api.version": "v2") to the
+// server span of every request routed through the group. The tracing middleware must run first
+func (i *Integration) TraceGroup(group RouteGroup, attrs map[string]interface{}) {
+	converted := convertToAttributes(attrs)
+
+	group.Use(func(c *vayu.Context, next vayu.NextFunc) {
+		ActiveSpan(c).Span.SetAttributes(converted...)
+		next()
+	})
+}
+
+// TracedGET registers a GET route whose handler runs in its own child span
+func (i *Integration) TracedGET(app *vayu.App, path string, handler vayu.HandlerFunc) {
+	app.GET(path, i.TraceHandler(path, handler))
+}
+
+// TracedPOST registers a POST route whose handler runs in its own child span
+func (i *Integration) TracedPOST(app *vayu.App, path string, handler vayu.HandlerFunc) {
+	app.POST(path, i.TraceHandler(path, handler))
+}
+
+// TracedPUT registers a PUT route whose handler runs in its own child span
+func (i *Integration) TracedPUT(app *vayu.App, path string, handler vayu.HandlerFunc) {
+	app.PUT(path, i.TraceHandler(path, handler))
+}
+
+// TracedDELETE registers a DELETE route whose handler runs in its own child span
+func (i *Integration) TracedDELETE(app *vayu.App, path string, handler vayu.HandlerFunc) {
+	app.DELETE(path, i.TraceHandler(path, handler))
+}
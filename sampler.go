@@ -0,0 +1,121 @@
+package vayuotel
+
+import (
+	"context"
+	"fmt"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RouteSampler is a sampler that selects a per-route sampler based on the route
+// template matched by the middleware, falling back to a default sampler
+type RouteSampler struct {
+	defaultSampler sdktrace.Sampler
+	overrides      map[string]sdktrace.Sampler
+}
+
+// NewRouteSampler creates a sampler that uses overrides keyed by route template
+// (e.g. "/checkout" or "/users/:id") and defaultSampler for all other requests
+// Templates must be registered with Integration.RegisterRoutes or TraceHandler to be matched
+func NewRouteSampler(defaultSampler sdktrace.Sampler, overrides map[string]sdktrace.Sampler) *RouteSampler {
+	if defaultSampler == nil {
+		defaultSampler = sdktrace.AlwaysSample()
+	}
+
+	routes := make(map[string]sdktrace.Sampler, len(overrides))
+	for route, sampler := range overrides {
+		routes[route] = sampler
+	}
+
+	return &RouteSampler{
+		defaultSampler: defaultSampler,
+		overrides:      routes,
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s *RouteSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if route, ok := routeFromContext(p.ParentContext); ok {
+		if sampler, ok := s.overrides[route]; ok {
+			return sampler.ShouldSample(p)
+		}
+	}
+	return s.defaultSampler.ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler
+func (s *RouteSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{default:%s,routes:%d}", s.defaultSampler.Description(), len(s.overrides))
+}
+
+// routeFromContext returns the route template stored in the context by the middleware
+func routeFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	route, ok := ctx.Value(routeKey).(string)
+	return route, ok
+}
+
+// PrioritySampler always samples requests whose priority, computed by the middleware's
+// RoutePriority option, is at least the minimum, and defers to a default sampler otherwise
+type PrioritySampler struct {
+	defaultSampler sdktrace.Sampler
+	minPriority    int
+}
+
+// NewPrioritySampler creates a sampler that samples requests with priority >= minPriority
+// and uses defaultSampler (e.g. sdktrace.TraceIDRatioBased(0.05)) for all others
+func NewPrioritySampler(defaultSampler sdktrace.Sampler, minPriority int) *PrioritySampler {
+	if defaultSampler == nil {
+		defaultSampler = sdktrace.AlwaysSample()
+	}
+
+	return &PrioritySampler{
+		defaultSampler: defaultSampler,
+		minPriority:    minPriority,
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s *PrioritySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.ParentContext != nil {
+		if priority, ok := p.ParentContext.Value(routePriorityKey).(int); ok && priority >= s.minPriority {
+			return sdktrace.SamplingResult{
+				Decision:   sdktrace.RecordAndSample,
+				Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+			}
+		}
+	}
+	return s.defaultSampler.ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler
+func (s *PrioritySampler) Description() string {
+	return fmt.Sprintf("PrioritySampler{default:%s,min:%d}", s.defaultSampler.Description(), s.minPriority)
+}
+
+// forceSampler samples spans whose parent context was marked by the middleware (e.g. for the
+// debug header) and defers to the base sampler for all others
+type forceSampler struct {
+	base sdktrace.Sampler
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s forceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.ParentContext != nil {
+		if force, _ := p.ParentContext.Value(forceSampleKey).(bool); force {
+			return sdktrace.SamplingResult{
+				Decision:   sdktrace.RecordAndSample,
+				Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+			}
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler
+func (s forceSampler) Description() string {
+	return fmt.Sprintf("ForceSampler{%s}", s.base.Description())
+}
@@ -0,0 +1,95 @@
+package vayuotel
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Semantic convention versions for the attribute names emitted by the middleware
+const (
+	// SemConvLegacy emits the legacy names (http.method, http.status_code, ...) from semconv v1.4.0
+	SemConvLegacy = "legacy"
+
+	// SemConvStable emits the stable names (http.request.method, http.response.status_code,
+	// url.path, server.address, ...) from semconv v1.21+
+	SemConvStable = "stable"
+
+	// SemConvBoth emits both legacy and stable names, for migrating dashboards and alerts
+	SemConvBoth = "both"
+)
+
+// emitLegacy reports whether legacy attribute names should be emitted for the version
+func emitLegacy(version string) bool {
+	return version != SemConvStable
+}
+
+// emitStable reports whether stable attribute names should be emitted for the version
+func emitStable(version string) bool {
+	return version == SemConvStable || version == SemConvBoth
+}
+
+// httpRequestAttributes returns the default request attributes using the naming of the given version,
+// with the values of the redacted query parameters replaced in the URL
+func httpRequestAttributes(r *http.Request, version string, redact []string) []attribute.KeyValue {
+	u := redactURL(r.URL, redact)
+	scheme := getScheme(r)
+	clientIP := getClientIP(r)
+	protocolVersion := getProtocolVersion(r)
+
+	var attrs []attribute.KeyValue
+	if emitLegacy(version) {
+		attrs = append(attrs,
+			attribute.String("http.method", r.Method),
+			attribute.String("http.url", u.String()),
+			attribute.String("http.host", r.Host),
+			attribute.String("http.user_agent", r.UserAgent()),
+			attribute.String("http.scheme", scheme),
+			attribute.String("http.target", r.URL.Path),
+			attribute.String("http.client_ip", clientIP),
+			attribute.String("net.protocol.version", protocolVersion),
+		)
+	}
+
+	if emitStable(version) {
+		attrs = append(attrs,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.full", u.String()),
+			attribute.String("url.scheme", scheme),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("user_agent.original", r.UserAgent()),
+			attribute.String("client.address", clientIP),
+			attribute.String("network.protocol.version", protocolVersion),
+		)
+
+		if u.RawQuery != "" {
+			attrs = append(attrs, attribute.String("url.query", u.RawQuery))
+		}
+
+		// Split the host header into address and port
+		host, port, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		attrs = append(attrs, attribute.String("server.address", host))
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, attribute.Int("server.port", p))
+		}
+	}
+
+	return attrs
+}
+
+// httpStatusAttributes returns the response status code attributes using the naming of the given version
+func httpStatusAttributes(statusCode int, version string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if emitLegacy(version) {
+		attrs = append(attrs, attribute.Int("http.status_code", statusCode))
+	}
+	if emitStable(version) {
+		attrs = append(attrs, attribute.Int("http.response.status_code", statusCode))
+	}
+	return attrs
+}
@@ -2,8 +2,12 @@ package vayuotel
 
 import (
 	"context"
+	"math"
+	"reflect"
+	"strconv"
 	"time"
 
+	"github.com/kaushiksamanta/vayu"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
@@ -13,6 +17,9 @@ import (
 type Span struct {
 	Span trace.Span
 	ctx  context.Context
+
+	// parentCtx is the context the span was started from, used by StartSibling
+	parentCtx context.Context
 }
 
 // convertToAttributes converts a map of interface{} values to OpenTelemetry attributes
@@ -44,6 +51,70 @@ func (s *Span) AddAttributes(attributes map[string]interface{}) *Span {
 	return s
 }
 
+// AddStruct adds the exported fields of struct v (or a pointer to one) as attributes named
+// "<prefix>.<name>", where name is taken from the field's `otel:"name"` tag or else the field
+// name. Pointer fields are recorded by their value, and unsigned integers too large for an
+// int64 as strings. Fields tagged `otel:"-"`, nil pointers and fields of unsupported types are skipped
+func (s *Span) AddStruct(prefix string, v interface{}) *Span {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return s
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return s
+	}
+
+	attributes := make(map[string]interface{}, rv.NumField())
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("otel"); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		// Normalize named and sized types to the types convertToAttributes handles
+		fv := rv.Field(i)
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			attributes[name] = fv.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			attributes[name] = fv.Int()
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			attributes[name] = int64(fv.Uint())
+		case reflect.Uint, reflect.Uint64:
+			if u := fv.Uint(); u <= math.MaxInt64 {
+				attributes[name] = int64(u)
+			} else {
+				attributes[name] = strconv.FormatUint(u, 10)
+			}
+		case reflect.Float32, reflect.Float64:
+			attributes[name] = fv.Float()
+		case reflect.Bool:
+			attributes[name] = fv.Bool()
+		case reflect.Struct:
+			if t, ok := fv.Interface().(time.Time); ok {
+				attributes[name] = t
+			}
+		}
+	}
+	return s.AddAttributes(attributes)
+}
+
 // AddEvent adds an event to the span and returns the span for chaining
 func (s *Span) AddEvent(name string, attributes ...map[string]interface{}) *Span {
 	var attrs []attribute.KeyValue
@@ -54,16 +125,77 @@ func (s *Span) AddEvent(name string, attributes ...map[string]interface{}) *Span
 	return s
 }
 
-// RecordError records an error on the span and returns the span for chaining
+// AddEventAt adds an event that happened at t to the span and returns the span for chaining
+func (s *Span) AddEventAt(name string, t time.Time, attributes ...map[string]interface{}) *Span {
+	var attrs []attribute.KeyValue
+	if len(attributes) > 0 && attributes[0] != nil {
+		attrs = convertToAttributes(attributes[0])
+	}
+	s.Span.AddEvent(name, trace.WithTimestamp(t), trace.WithAttributes(attrs...))
+	return s
+}
+
+// SpanEvent is an event recorded with AddEvents; a zero Time means the time it is added
+type SpanEvent struct {
+	Name       string
+	Attributes map[string]interface{}
+	Time       time.Time
+}
+
+// AddEvents adds a batch of events (e.g. state machine transitions) to the span in order
+// and returns the span for chaining
+func (s *Span) AddEvents(events []SpanEvent) *Span {
+	for _, event := range events {
+		opts := []trace.EventOption{trace.WithAttributes(convertToAttributes(event.Attributes)...)}
+		if !event.Time.IsZero() {
+			opts = append(opts, trace.WithTimestamp(event.Time))
+		}
+		s.Span.AddEvent(event.Name, opts...)
+	}
+	return s
+}
+
+// RecordError records an error on the span, sets an "error.type" attribute so backends
+// can group by error type, and returns the span for chaining. A nil error is ignored
 func (s *Span) RecordError(err error) *Span {
+	if err == nil {
+		return s
+	}
+
 	s.Span.RecordError(err)
 	s.Span.SetStatus(codes.Error, err.Error())
+	s.Span.SetAttributes(attribute.String("error.type", errorType(err)))
 	return s
 }
 
-// End ends the span
-func (s *Span) End() {
-	s.Span.End()
+// RecordErrorWithCode records an error like RecordError and adds a stable application
+// error code as the "error.code" attribute
+func (s *Span) RecordErrorWithCode(err error, code string) *Span {
+	if err == nil {
+		return s
+	}
+
+	s.RecordError(err)
+	s.Span.SetAttributes(attribute.String("error.code", code))
+	return s
+}
+
+// errorType returns the Go type name of err (e.g. "*fs.PathError"), or "" for a nil error
+func errorType(err error) string {
+	if err == nil {
+		return ""
+	}
+	return reflect.TypeOf(err).String()
+}
+
+// End ends the span, applying any OpenTelemetry end options (e.g. trace.WithTimestamp)
+func (s *Span) End(opts ...trace.SpanEndOption) {
+	s.Span.End(opts...)
+}
+
+// EndWithOptions ends the span at endTime, for spans representing work that finished earlier
+func (s *Span) EndWithOptions(endTime time.Time) {
+	s.Span.End(trace.WithTimestamp(endTime))
 }
 
 // Context returns the context associated with this span
@@ -71,22 +203,116 @@ func (s *Span) Context() context.Context {
 	return s.ctx
 }
 
-// Start creates a span from the context and returns our wrapper Span
+// IsRecording reports whether the span is recording, so costly attributes can be skipped when it is not
+func (s *Span) IsRecording() bool {
+	return s.Span.IsRecording()
+}
+
+// SpanContext returns the span's trace and span IDs
+func (s *Span) SpanContext() trace.SpanContext {
+	return s.Span.SpanContext()
+}
+
+// NoopSpan returns a Span wrapping the no-op span, on which all methods are safe no-ops.
+// Helpers return it instead of nil so callers never need to nil-check
+func NoopSpan() *Span {
+	ctx := context.Background()
+	return &Span{
+		Span: trace.SpanFromContext(ctx),
+		ctx:  ctx,
+	}
+}
+
+// TraceState returns the span's W3C trace state
+func (s *Span) TraceState() trace.TraceState {
+	return s.Span.SpanContext().TraceState()
+}
+
+// Start creates a span from the context and returns our wrapper Span. It never returns nil
 func Start(ctx context.Context, name string, opts ...SpanOption) *Span {
+	return startSpan(ctx, name, nil, opts...)
+}
+
+// StartContext is like Start but also returns the span's context, for callers that pass
+// it straight into function calls
+func StartContext(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	span := startSpan(ctx, name, nil, opts...)
+	return span.Context(), span
+}
+
+// StartRaw starts a span like Start but returns the raw OpenTelemetry span, for code that
+// works with trace.Span directly
+func StartRaw(ctx context.Context, name string, opts ...SpanOption) (context.Context, trace.Span) {
+	span := startSpan(ctx, name, nil, opts...)
+	return span.Context(), span.Span
+}
+
+// WithSpan starts a span, runs fn with the span's context, records any returned error on the span,
+// and ends the span even if fn panics. The error from fn is returned unchanged
+func WithSpan(ctx context.Context, name string, fn func(ctx context.Context, span *Span) error) error {
+	span := Start(ctx, name)
+	defer span.End()
+
+	if err := fn(span.Context(), span); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// startSpan creates a child span using the given OpenTelemetry start options
+// (e.g. span kind) and applies our span options to it
+func startSpan(ctx context.Context, name string, startOpts []trace.SpanStartOption, opts ...SpanOption) *Span {
+	// Skip span creation in contexts marked by SuppressTracing, keeping the marker for descendants
+	if suppressed, _ := ctx.Value(suppressTracingKey).(bool); suppressed {
+		return &Span{
+			Span:      trace.SpanFromContext(context.Background()),
+			ctx:       ctx,
+			parentCtx: ctx,
+		}
+	}
+
 	// Get the current span from the context
 	currentSpan := trace.SpanFromContext(ctx)
 
-	// Get the tracer provider from the current span
+	// Get the tracer provider from the current span, or from the context for root spans
 	tracerProvider := currentSpan.TracerProvider()
+	if !currentSpan.SpanContext().IsValid() {
+		if tp, ok := ctx.Value(tracerProviderKey).(trace.TracerProvider); ok {
+			tracerProvider = tp
+		}
+	}
 
-	// Always get the tracer name from the context
-	tracerName := ctx.Value(tracerNameKey).(string)
+	// Get the tracer name from the context, falling back to the default
+	tracerName, ok := ctx.Value(tracerNameKey).(string)
+	if !ok {
+		tracerName = tracerNameValue
+	}
 
-	// Get the tracer with the appropriate name
-	tracer := tracerProvider.Tracer(tracerName)
+	// Get the tracer with the appropriate name and version
+	var tracerOpts []trace.TracerOption
+	if version, ok := ctx.Value(tracerVersionKey).(string); ok {
+		tracerOpts = append(tracerOpts, trace.WithInstrumentationVersion(version))
+	}
+	tracer := tracerProvider.Tracer(tracerName, tracerOpts...)
+
+	// Collect options that must be applied at span start
+	for _, opt := range opts {
+		if so, ok := opt.(spanStartOption); ok {
+			startOpts = append(startOpts, so.startOptions()...)
+		}
+	}
+
+	// Adjust the parent context for options inherited from it
+	parentCtx := ctx
+	for _, opt := range opts {
+		if po, ok := opt.(spanParentOption); ok {
+			parentCtx = po.parentContext(parentCtx)
+		}
+	}
 
 	// Create a new child span
-	newCtx, span := tracer.Start(ctx, name)
+	newCtx, span := tracer.Start(parentCtx, name, startOpts...)
 
 	// Apply options
 	for _, opt := range opts {
@@ -94,8 +320,66 @@ func Start(ctx context.Context, name string, opts ...SpanOption) *Span {
 	}
 
 	// Return our wrapper Span
+	return &Span{
+		Span:      span,
+		ctx:       newCtx,
+		parentCtx: ctx,
+	}
+}
+
+// StartSibling starts a span with the same parent as s, so both appear side by side in the
+// trace. Spans not started with Start (e.g. ActiveSpan) have no known parent, so their
+// siblings are started as new root spans from the same tracer provider
+func StartSibling(s *Span, name string, opts ...SpanOption) *Span {
+	ctx := s.parentCtx
+	if ctx == nil {
+		ctx = context.WithValue(s.ctx, tracerProviderKey, s.Span.TracerProvider())
+		ctx = trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+	}
+	return startSpan(ctx, name, nil, opts...)
+}
+
+// ActiveSpan returns the span stored in the request context by the middleware
+// If no span is active, the returned wrapper holds a no-op span and is safe to use
+func ActiveSpan(c *vayu.Context) *Span {
+	if c == nil || c.Request == nil {
+		return NoopSpan()
+	}
+
+	// Prefer the server span, which TraceHandler's span replaces as the current span
+	ctx := c.Request.Context()
+	span, ok := ctx.Value(serverSpanKey).(trace.Span)
+	if !ok {
+		span = trace.SpanFromContext(ctx)
+	} else if !span.SpanContext().Equal(trace.SpanContextFromContext(ctx)) {
+		ctx = trace.ContextWithSpan(ctx, span)
+	}
 	return &Span{
 		Span: span,
-		ctx:  newCtx,
+		ctx:  ctx,
+	}
+}
+
+// AddServerSpanAttributes sets attributes learned mid-request (e.g. the resolved user ID or a
+// cache hit) on the server span started by the middleware rather than on a child span
+func AddServerSpanAttributes(c *vayu.Context, attributes map[string]interface{}) {
+	ActiveSpan(c).AddAttributes(attributes)
+}
+
+// GetTraceID returns the hex trace ID of the request's active span, or "" if there is none
+func GetTraceID(c *vayu.Context) string {
+	sc := ActiveSpan(c).SpanContext()
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// GetSpanID returns the hex span ID of the request's active span, or "" if there is none
+func GetSpanID(c *vayu.Context) string {
+	sc := ActiveSpan(c).SpanContext()
+	if !sc.HasSpanID() {
+		return ""
 	}
+	return sc.SpanID().String()
 }
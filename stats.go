@@ -0,0 +1,92 @@
+package vayuotel
+
+import (
+	"context"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SpanStats reports how many sampled spans went through the export pipeline
+type SpanStats struct {
+	// Ended is the number of sampled spans that ended and were handed to the exporting processor
+	Ended int64
+
+	// Exported is the number of spans the exporter accepted
+	Exported int64
+
+	// Failed is the number of spans the exporter returned an error for
+	Failed int64
+
+	// Dropped is the number of ended spans that never reached the exporter (e.g. because the
+	// batch queue was full). Spans still queued are included until the provider is flushed
+	Dropped int64
+
+	// LastExportSucceeded reports whether the most recent export succeeded; it is false
+	// until the first export
+	LastExportSucceeded bool
+}
+
+// spanCounters tallies spans entering and leaving the export pipeline
+type spanCounters struct {
+	ended, exported, failed atomic.Int64
+	lastExportSucceeded     atomic.Bool
+}
+
+// snapshot returns the current counts
+func (c *spanCounters) snapshot() SpanStats {
+	stats := SpanStats{
+		Ended:               c.ended.Load(),
+		Exported:            c.exported.Load(),
+		Failed:              c.failed.Load(),
+		LastExportSucceeded: c.lastExportSucceeded.Load(),
+	}
+	if dropped := stats.Ended - stats.Exported - stats.Failed; dropped > 0 {
+		stats.Dropped = dropped
+	}
+	return stats
+}
+
+// countingProcessor counts sampled spans handed to the wrapped exporting processor
+type countingProcessor struct {
+	sdktrace.SpanProcessor
+	counters *spanCounters
+}
+
+// OnEnd implements sdktrace.SpanProcessor
+func (p countingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.counters.ended.Add(1)
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// countingExporter counts spans exported successfully and unsuccessfully by the wrapped exporter,
+// reporting failed exports to the logger
+type countingExporter struct {
+	sdktrace.SpanExporter
+	counters *spanCounters
+	logger   Logger
+}
+
+// ExportSpans implements sdktrace.SpanExporter
+func (e countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.counters.lastExportSucceeded.Store(err == nil)
+	if err != nil {
+		e.counters.failed.Add(int64(len(spans)))
+		e.logger.Error(ctx, "failed to export spans", "spans", len(spans), "error", err)
+	} else {
+		e.counters.exported.Add(int64(len(spans)))
+	}
+	return err
+}
+
+// Stats returns counts of ended, exported, failed and dropped spans, so silent drops
+// under load can be detected
+func (p *Provider) Stats() SpanStats {
+	if p.stats == nil {
+		return SpanStats{}
+	}
+	return p.stats.snapshot()
+}
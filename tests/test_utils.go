@@ -2,12 +2,21 @@ package tests
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -72,3 +81,92 @@ func SetupTestTracer() (*Provider, error) {
 		Exporter:       exporter,
 	}, nil
 }
+
+// InMemoryExporter is an in-memory span exporter that keeps its spans after Shutdown
+// so tests can inspect them once the integration has been flushed and shut down
+type InMemoryExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+// NewInMemoryExporter creates a new InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+}
+
+// Shutdown is a no-op so recorded spans remain available
+func (e *InMemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// SetupTestIntegration creates an integration that exports spans to the given exporter
+// Optional configure functions can adjust the configuration before setup
+func SetupTestIntegration(exporter sdktrace.SpanExporter, configure ...func(cfg *vayuOtel.Config)) (*vayuOtel.Integration, error) {
+	options := vayuOtel.DefaultSetupOptions()
+	options.App = vayu.New()
+	options.Config.ServiceName = "test-service"
+	options.Config.Exporter = exporter
+	for _, fn := range configure {
+		fn(&options.Config)
+	}
+	return vayuOtel.Setup(options)
+}
+
+// ServeMiddleware runs the middleware for the request with handler as the next step in the chain
+func ServeMiddleware(middleware vayu.HandlerFunc, req *http.Request, handler func(c *vayu.Context)) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c := &vayu.Context{
+		Request: req,
+		Writer:  recorder,
+		Params:  make(map[string]string),
+	}
+	middleware(c, func() {
+		if handler != nil {
+			handler(c)
+		}
+	})
+	return recorder
+}
+
+// FindAttribute returns the value of the attribute with the given key
+func FindAttribute(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// fixedIDGenerator hands out the same trace ID for every trace and sequential span IDs
+type fixedIDGenerator struct {
+	traceID trace.TraceID
+	first   uint64
+	next    atomic.Uint64
+}
+
+// NewIDs implements sdktrace.IDGenerator
+func (g *fixedIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	return g.traceID, g.NewSpanID(ctx, g.traceID)
+}
+
+// NewSpanID implements sdktrace.IDGenerator
+func (g *fixedIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(sid[:], g.first+g.next.Add(1)-1)
+	return sid
+}
+
+// FixedIDGenerator returns an ID generator for Config.IDGenerator that gives every span the
+// hex trace ID, and span IDs counting up from the hex span ID, so tests can assert on
+// predictable IDs. It panics on invalid IDs
+func FixedIDGenerator(traceID, spanID string) sdktrace.IDGenerator {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		panic(fmt.Sprintf("invalid trace ID %q: %v", traceID, err))
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		panic(fmt.Sprintf("invalid span ID %q: %v", spanID, err))
+	}
+	return &fixedIDGenerator{traceID: tid, first: binary.BigEndian.Uint64(sid[:])}
+}
@@ -0,0 +1,49 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+)
+
+func TestTracedLogger(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter())
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	var out bytes.Buffer
+	tracing := integration.Middleware()
+
+	// The logger is registered before the tracing middleware, as with app.Use order
+	var traceID string
+	tests.ServeMiddleware(vayuOtel.TracedLogger(&out), httptest.NewRequest(http.MethodGet, "/orders", nil), func(c *vayu.Context) {
+		tracing(c, func() {
+			traceID = vayuOtel.GetTraceID(c)
+			c.Writer.WriteHeader(http.StatusCreated)
+		})
+	})
+
+	line := out.String()
+	if !strings.Contains(line, "trace_id="+traceID) || traceID == "" {
+		t.Errorf("Expected the log line to contain trace_id=%s, got %q", traceID, line)
+	}
+	if !strings.Contains(line, "GET /orders 201") {
+		t.Errorf("Expected the log line to contain the method, path and status, got %q", line)
+	}
+
+	// Requests without a span are logged with placeholders
+	out.Reset()
+	tests.ServeMiddleware(vayuOtel.TracedLogger(&out), httptest.NewRequest(http.MethodGet, "/health", nil), nil)
+	if !strings.Contains(out.String(), "trace_id=- span_id=-") {
+		t.Errorf("Expected placeholder IDs without a span, got %q", out.String())
+	}
+}
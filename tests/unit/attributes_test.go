@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"testing"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSliceAttributes(t *testing.T) {
+	testCases := []struct {
+		name string
+		kv   attribute.KeyValue
+		want attribute.Type
+	}{
+		{"string slice", vayuOtel.StringSliceAttribute("tags", []string{"a", "b"}), attribute.STRINGSLICE},
+		{"int slice", vayuOtel.IntSliceAttribute("ids", []int{1, 2}), attribute.INT64SLICE},
+		{"float64 slice", vayuOtel.Float64SliceAttribute("scores", []float64{0.5}), attribute.FLOAT64SLICE},
+		{"bool slice", vayuOtel.BoolSliceAttribute("flags", []bool{true}), attribute.BOOLSLICE},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.kv.Value.Type(); got != tc.want {
+				t.Errorf("Expected type %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSliceAttributeOptions(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	vayuOtel.Start(ctx, "sliced",
+		vayuOtel.WithStringSliceAttribute("tags", []string{"a", "b"}),
+		vayuOtel.WithIntSliceAttribute("ids", []int{1, 2}),
+		vayuOtel.WithFloat64SliceAttribute("scores", []float64{0.5}),
+		vayuOtel.WithBoolSliceAttribute("flags", []bool{true, false}),
+	).End()
+
+	attrs := findSpan(t, collect(), "sliced").Attributes
+	if v, _ := tests.FindAttribute(attrs, "tags"); len(v.AsStringSlice()) != 2 {
+		t.Errorf("Expected 2 tags, got %v", v.AsStringSlice())
+	}
+	if v, _ := tests.FindAttribute(attrs, "ids"); len(v.AsInt64Slice()) != 2 {
+		t.Errorf("Expected 2 ids, got %v", v.AsInt64Slice())
+	}
+	if v, _ := tests.FindAttribute(attrs, "scores"); len(v.AsFloat64Slice()) != 1 {
+		t.Errorf("Expected 1 score, got %v", v.AsFloat64Slice())
+	}
+	if v, _ := tests.FindAttribute(attrs, "flags"); len(v.AsBoolSlice()) != 2 {
+		t.Errorf("Expected 2 flags, got %v", v.AsBoolSlice())
+	}
+}
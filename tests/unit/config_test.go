@@ -2,11 +2,26 @@ package unit
 
 import (
 	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/kaushiksamanta/vayu"
 	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -40,6 +55,14 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.BatchTimeout != 5*time.Second {
 		t.Errorf("Expected BatchTimeout to be 5s, got %v", cfg.BatchTimeout)
 	}
+
+	if cfg.MaxQueueSize != 2048 {
+		t.Errorf("Expected MaxQueueSize to be 2048, got %d", cfg.MaxQueueSize)
+	}
+
+	if cfg.ExportTimeout != 30*time.Second {
+		t.Errorf("Expected ExportTimeout to be 30s, got %v", cfg.ExportTimeout)
+	}
 }
 
 func TestProviderShutdown(t *testing.T) {
@@ -63,3 +86,707 @@ func TestProviderShutdown(t *testing.T) {
 		t.Errorf("Failed to shut down valid provider: %v", err)
 	}
 }
+
+func TestProviderForceFlush(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.BatchTimeout = time.Hour // Make sure only the flush exports the span
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "flushed-span")
+	span.End()
+
+	if got := len(exporter.GetSpans()); got != 0 {
+		t.Fatalf("Expected no spans before flush, got %d", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := provider.ForceFlush(ctx); err != nil {
+		t.Fatalf("Failed to force flush provider: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "flushed-span" {
+		t.Errorf("Expected flushed-span to be exported after flush, got %d spans", len(spans))
+	}
+}
+
+func TestProviderForceFlushExpiredContext(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := provider.ForceFlush(ctx); err == nil {
+		t.Error("Expected error when flushing with a cancelled context")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := vayuOtel.DefaultConfig().Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(cfg *vayuOtel.Config)
+	}{
+		{"empty service name", func(cfg *vayuOtel.Config) { cfg.ServiceName = "" }},
+		{"empty OTLP endpoint", func(cfg *vayuOtel.Config) { cfg.OTLPEndpoint = "" }},
+		{"negative batch size", func(cfg *vayuOtel.Config) { cfg.BatchSize = -1 }},
+		{"negative batch timeout", func(cfg *vayuOtel.Config) { cfg.BatchTimeout = -time.Second }},
+		{"negative max queue size", func(cfg *vayuOtel.Config) { cfg.MaxQueueSize = -1 }},
+		{"negative export timeout", func(cfg *vayuOtel.Config) { cfg.ExportTimeout = -time.Second }},
+		{"unknown OTLP protocol", func(cfg *vayuOtel.Config) { cfg.OTLPProtocol = "carrier-pigeon" }},
+		{"negative retry interval", func(cfg *vayuOtel.Config) { cfg.Retry.InitialInterval = -time.Second }},
+		{"unknown compression", func(cfg *vayuOtel.Config) { cfg.Compression = "brotli" }},
+		{"sample ratio above one", func(cfg *vayuOtel.Config) { cfg.SampleRatio = 1.5 }},
+		{"unknown export mode", func(cfg *vayuOtel.Config) { cfg.ExportMode = "eventual" }},
+		{"sync export with batch mode", func(cfg *vayuOtel.Config) {
+			cfg.SyncExport = true
+			cfg.ExportMode = vayuOtel.ExportModeBatch
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := vayuOtel.DefaultConfig()
+			tc.modify(&cfg)
+
+			if err := cfg.Validate(); !errors.Is(err, vayuOtel.ErrInvalidConfig) {
+				t.Errorf("Expected ErrInvalidConfig, got %v", err)
+			}
+
+			if _, err := vayuOtel.NewProvider(cfg); !errors.Is(err, vayuOtel.ErrInvalidConfig) {
+				t.Errorf("Expected NewProvider to return ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+
+	// An empty endpoint is fine when printing to stdout
+	cfg := vayuOtel.DefaultConfig()
+	cfg.OTLPEndpoint = ""
+	cfg.UseStdout = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected stdout config without endpoint to be valid, got %v", err)
+	}
+}
+
+func TestProviderCompression(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Compression = vayuOtel.CompressionGzip
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with gzip compression: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	provider.Shutdown(ctx)
+}
+
+func TestProviderRetryConfig(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Retry = &vayuOtel.RetryConfig{
+		Enabled:         true,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		MaxElapsedTime:  5 * time.Second,
+	}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with retry config: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	provider.Shutdown(ctx)
+}
+
+// unavailableCollector rejects every export with a retryable error and counts the attempts
+type unavailableCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	attempts atomic.Int32
+}
+
+func (c *unavailableCollector) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	c.attempts.Add(1)
+	return nil, status.Error(codes.Unavailable, "collector unavailable")
+}
+
+func TestProviderRetryDisabled(t *testing.T) {
+	for _, tc := range []struct {
+		retry    *vayuOtel.RetryConfig
+		attempts func(n int32) bool
+	}{
+		// A zero RetryConfig turns retries off rather than keeping the exporter's default
+		{&vayuOtel.RetryConfig{}, func(n int32) bool { return n == 1 }},
+		{&vayuOtel.RetryConfig{
+			Enabled:         true,
+			InitialInterval: 10 * time.Millisecond,
+			MaxInterval:     10 * time.Millisecond,
+			MaxElapsedTime:  200 * time.Millisecond,
+		}, func(n int32) bool { return n > 1 }},
+	} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		collector := &unavailableCollector{}
+		server := grpc.NewServer()
+		coltracepb.RegisterTraceServiceServer(server, collector)
+		go server.Serve(listener)
+
+		cfg := vayuOtel.DefaultConfig()
+		cfg.OTLPEndpoint = listener.Addr().String()
+		cfg.ExportMode = vayuOtel.ExportModeSimple
+		cfg.DisableGlobal = true
+		cfg.Retry = tc.retry
+
+		provider, err := vayuOtel.NewProvider(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+
+		_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "work")
+		span.End()
+		provider.Shutdown(context.Background())
+		server.Stop()
+
+		if n := collector.attempts.Load(); !tc.attempts(n) {
+			t.Errorf("Retry enabled=%t: unexpected number of export attempts %d", tc.retry.Enabled, n)
+		}
+	}
+}
+
+func TestProviderBlockOnConnectTimeout(t *testing.T) {
+	// Nothing listens on this port, so a blocking connect must give up after the export timeout
+	cfg := vayuOtel.DefaultConfig()
+	cfg.OTLPEndpoint = "127.0.0.1:1"
+	cfg.BlockOnConnect = true
+	cfg.ExportTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	if _, err := vayuOtel.NewProvider(cfg); err == nil {
+		t.Error("Expected an error when the collector is unreachable")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected NewProvider to give up quickly, took %v", elapsed)
+	}
+}
+
+func TestProviderJaegerEndpoint(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.OTLPEndpoint = ""
+	cfg.JaegerEndpoint = "http://localhost:14268/api/traces"
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with Jaeger endpoint: %v", err)
+	}
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Errorf("Failed to shut down provider: %v", err)
+	}
+}
+
+func TestProviderSyncExport(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.SyncExport = true
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "sync-span")
+	span.End()
+
+	// No flush: the span must already have been exported when End returned
+	if len(exporter.GetSpans()) != 1 {
+		t.Errorf("Expected span to be exported synchronously, got %d spans", len(exporter.GetSpans()))
+	}
+}
+
+func TestProviderGRPCConn(t *testing.T) {
+	conn, err := grpc.Dial("127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client connection: %v", err)
+	}
+	defer conn.Close()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.GRPCConn = conn
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with a shared connection: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	provider.Shutdown(ctx)
+}
+
+func TestProviderExtraDialOptions(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.ExtraDialOptions = []grpc.DialOption{grpc.WithUserAgent("vayu-otel-test")}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with extra dial options: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	provider.Shutdown(ctx)
+}
+
+func TestProviderQueueTuning(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.MaxQueueSize = 16
+	cfg.BatchSize = 8
+	cfg.ExportTimeout = time.Second
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with queue tuning: %v", err)
+	}
+
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "tuned-span")
+	span.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	if len(exporter.GetSpans()) != 1 {
+		t.Errorf("Expected 1 exported span, got %d", len(exporter.GetSpans()))
+	}
+}
+
+func TestDisabledProvider(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+		cfg.Disabled = true
+		cfg.ServiceName = ""
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up disabled integration: %v", err)
+	}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Disabled = true
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create disabled provider: %v", err)
+	}
+
+	if provider.TracerProvider != nil {
+		t.Error("Expected no SDK tracer provider when disabled")
+	}
+
+	var recording bool
+	req := httptest.NewRequest(http.MethodGet, "/disabled", nil)
+	tests.ServeMiddleware(integration.Middleware(), req, func(c *vayu.Context) {
+		span := vayuOtel.Start(c.Request.Context(), "child")
+		span.AddAttributes(map[string]interface{}{"key": "value"}).
+			AddEvent("event").
+			RecordError(errors.New("test error"))
+		recording = span.Span.IsRecording()
+		span.End()
+	})
+
+	if recording {
+		t.Error("Expected spans not to be recorded when disabled")
+	}
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down disabled integration: %v", err)
+	}
+
+	if len(exporter.GetSpans()) != 0 {
+		t.Errorf("Expected no spans to be exported when disabled, got %d", len(exporter.GetSpans()))
+	}
+}
+
+func TestProviderSpanLimits(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.SpanLimits = vayuOtel.SpanLimits{
+		MaxAttributeCount:       2,
+		MaxAttributeValueLength: 8,
+	}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider with span limits: %v", err)
+	}
+
+	ctx, root := provider.TracerProvider.Tracer("test").Start(context.Background(), "root")
+	span := vayuOtel.Start(ctx, "limited-span")
+	span.AddAttributes(map[string]interface{}{"long": "a very long attribute value"})
+	span.Span.SetAttributes(
+		vayuOtel.StringAttribute("second", "b"),
+		vayuOtel.StringAttribute("third", "c"),
+	)
+	span.End()
+	root.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	limited := findSpan(t, exporter.GetSpans(), "limited-span")
+	if len(limited.Attributes) != 2 {
+		t.Errorf("Expected 2 attributes, got %d", len(limited.Attributes))
+	}
+
+	if limited.DroppedAttributes != 1 {
+		t.Errorf("Expected 1 dropped attribute, got %d", limited.DroppedAttributes)
+	}
+
+	if v, _ := tests.FindAttribute(limited.Attributes, "long"); v.AsString() != "a very l" {
+		t.Errorf("Expected long attribute to be truncated to 'a very l', got %q", v.AsString())
+	}
+}
+
+func TestProviderSpanLimitsArePerProvider(t *testing.T) {
+	newProvider := func(limit int) (*vayuOtel.Provider, *tests.InMemoryExporter) {
+		exporter := tests.NewInMemoryExporter()
+		cfg := vayuOtel.DefaultConfig()
+		cfg.Exporter = exporter
+		cfg.SpanLimits.MaxAttributeValueLength = limit
+		provider, err := vayuOtel.NewProvider(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+		return provider, exporter
+	}
+
+	// Creating a second provider must not change the first provider's limit
+	first, firstExporter := newProvider(4)
+	second, secondExporter := newProvider(3)
+
+	for _, p := range []*vayuOtel.Provider{first, second} {
+		ctx, root := p.TracerProvider.Tracer("test").Start(context.Background(), "root")
+		vayuOtel.Start(ctx, "limited-span").AddAttributes(map[string]interface{}{"long": "abcdefgh"}).End()
+		root.End()
+		if err := p.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Failed to shut down provider: %v", err)
+		}
+	}
+
+	if v, _ := tests.FindAttribute(findSpan(t, firstExporter.GetSpans(), "limited-span").Attributes, "long"); v.AsString() != "abcd" {
+		t.Errorf("Expected the first provider to truncate to 'abcd', got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(findSpan(t, secondExporter.GetSpans(), "limited-span").Attributes, "long"); v.AsString() != "abc" {
+		t.Errorf("Expected the second provider to truncate to 'abc', got %q", v.AsString())
+	}
+}
+
+func TestSetupVerifyConnection(t *testing.T) {
+	options := vayuOtel.DefaultSetupOptions()
+	options.App = vayu.New()
+	options.Config.OTLPEndpoint = "127.0.0.1:1"
+	options.Config.VerifyConnection = true
+	options.Config.ExportTimeout = time.Second
+
+	previous := trace.NewTracerProvider()
+	otel.SetTracerProvider(previous)
+
+	_, err := vayuOtel.Setup(options)
+	if !errors.Is(err, vayuOtel.ErrCollectorUnreachable) {
+		t.Fatalf("Expected ErrCollectorUnreachable, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "127.0.0.1:1") {
+		t.Errorf("Expected error to name the collector address, got %q", err.Error())
+	}
+
+	// The shut down provider must not be left behind as the global
+	if otel.GetTracerProvider() != previous {
+		t.Error("Expected the global tracer provider to be unchanged after a failed connection check")
+	}
+}
+
+func TestSetupVerifyConnectionRegistersGlobals(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	options := vayuOtel.DefaultSetupOptions()
+	options.App = vayu.New()
+	options.Config.OTLPEndpoint = listener.Addr().String()
+	options.Config.VerifyConnection = true
+	options.Config.ExportTimeout = time.Second
+
+	integration, err := vayuOtel.Setup(options)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.ShutdownWithTimeout(time.Second)
+
+	if otel.GetTracerProvider() != integration.Provider().TracerProvider {
+		t.Error("Expected the verified provider to be registered as the global tracer provider")
+	}
+}
+
+func TestHealthCheckSkippedForCustomExporter(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	if err := provider.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected no health check error for a custom exporter, got %v", err)
+	}
+}
+
+func TestProviderConfigLiteralRegistersGlobals(t *testing.T) {
+	originalTracerProvider := otel.GetTracerProvider()
+	originalPropagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(originalTracerProvider)
+		otel.SetTextMapPropagator(originalPropagator)
+	})
+
+	// The zero value of the config keeps registering the globals
+	provider, err := vayuOtel.NewProvider(vayuOtel.Config{ServiceName: "test-service", Exporter: tests.NewInMemoryExporter()})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	if otel.GetTracerProvider() != provider.TracerProvider {
+		t.Error("Expected a config literal to register the global tracer provider")
+	}
+}
+
+func TestProviderWithoutGlobalRegistration(t *testing.T) {
+	// Install a recognizable global propagator, restoring the original afterwards
+	original := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.Baggage{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(original) })
+
+	globalTracerProvider := otel.GetTracerProvider()
+
+	exporters := []*tests.InMemoryExporter{tests.NewInMemoryExporter(), tests.NewInMemoryExporter()}
+	for i, exporter := range exporters {
+		integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+			cfg.DisableGlobal = true
+		})
+		if err != nil {
+			t.Fatalf("Failed to set up integration %d: %v", i, err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/isolated", nil)
+		tests.ServeMiddleware(integration.Middleware(), req, func(c *vayu.Context) {
+			vayuOtel.Start(c.Request.Context(), "child").End()
+		})
+
+		if err := integration.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Failed to shut down integration %d: %v", i, err)
+		}
+	}
+
+	if otel.GetTracerProvider() != globalTracerProvider {
+		t.Error("Expected the global tracer provider to be untouched")
+	}
+
+	if _, ok := otel.GetTextMapPropagator().(propagation.Baggage); !ok {
+		t.Error("Expected the global propagator to be untouched")
+	}
+
+	// Each isolated provider still records its own spans
+	for i, exporter := range exporters {
+		if len(exporter.GetSpans()) != 2 {
+			t.Errorf("Expected 2 spans from provider %d, got %d", i, len(exporter.GetSpans()))
+		}
+	}
+}
+
+func TestConfigClone(t *testing.T) {
+	original := vayuOtel.DefaultConfig()
+	original.Headers = map[string]string{"api-key": "original"}
+	original.AdditionalAttributes = []vayuOtel.ResourceAttribute{{Key: "team", Value: "payments"}}
+	original.RedactAttributes = []string{"user.email"}
+
+	clone := original.Clone()
+	clone.Headers["api-key"] = "changed"
+	clone.Headers["extra"] = "value"
+	clone.AdditionalAttributes[0].Value = "search"
+	clone.RedactAttributes[0] = "user.phone"
+
+	if original.Headers["api-key"] != "original" || len(original.Headers) != 1 {
+		t.Errorf("Expected original headers to be unaffected, got %v", original.Headers)
+	}
+	if original.AdditionalAttributes[0].Value != "payments" {
+		t.Errorf("Expected original attributes to be unaffected, got %v", original.AdditionalAttributes)
+	}
+	if original.RedactAttributes[0] != "user.email" {
+		t.Errorf("Expected original redact list to be unaffected, got %v", original.RedactAttributes)
+	}
+}
+
+func TestProviderSimpleExportMode(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.ExportMode = vayuOtel.ExportModeSimple
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "invocation")
+	span.End()
+
+	// No flush: the span is exported as soon as it ends
+	if got := len(exporter.GetSpans()); got != 1 {
+		t.Errorf("Expected the span to be exported on End, got %d spans", got)
+	}
+}
+
+func TestConfigOTLPTarget(t *testing.T) {
+	testCases := []struct {
+		endpoint     string
+		insecure     bool
+		address      string
+		wantInsecure bool
+	}{
+		{"http://collector.example.com:4317", false, "collector.example.com:4317", true},
+		{"https://collector.example.com:4317", true, "collector.example.com:4317", false},
+		{"https://collector.example.com", true, "collector.example.com:443", false},
+		{"collector.example.com:4318", true, "collector.example.com:4318", true},
+		{"collector.example.com:4318", false, "collector.example.com:4318", false},
+		{"collector.example.com", true, "collector.example.com:4317", true},
+	}
+
+	for _, tc := range testCases {
+		cfg := vayuOtel.DefaultConfig()
+		cfg.OTLPEndpoint = tc.endpoint
+		cfg.Insecure = tc.insecure
+
+		address, insecure, err := cfg.OTLPTarget()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.endpoint, err)
+			continue
+		}
+		if address != tc.address {
+			t.Errorf("%s: expected address %q, got %q", tc.endpoint, tc.address, address)
+		}
+		if insecure != tc.wantInsecure {
+			t.Errorf("%s: expected insecure=%v, got %v", tc.endpoint, tc.wantInsecure, insecure)
+		}
+	}
+
+	// Paths and unknown schemes cannot be used with gRPC
+	for _, endpoint := range []string{"https://collector.example.com/v1/traces", "ftp://collector.example.com"} {
+		cfg := vayuOtel.DefaultConfig()
+		cfg.OTLPEndpoint = endpoint
+		if err := cfg.Validate(); !errors.Is(err, vayuOtel.ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", endpoint, err)
+		}
+	}
+}
+
+func TestProviderRevisionResource(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.Revision = "3f2c1a9"
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	v, ok := exporter.GetSpans()[0].Resource.Set().Value("service.revision")
+	if !ok || v.AsString() != "3f2c1a9" {
+		t.Errorf("Expected service.revision=3f2c1a9 on the resource, got %q", v.AsString())
+	}
+}
+
+func TestProviderFixedIDGenerator(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.IDGenerator = tests.FixedIDGenerator("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	tracer := provider.TracerProvider.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	parent.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	parentSpan, childSpan := findSpan(t, spans, "parent"), findSpan(t, spans, "child")
+	if parentSpan.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the fixed trace ID, got %s", parentSpan.SpanContext.TraceID())
+	}
+	if parentSpan.SpanContext.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the fixed span ID, got %s", parentSpan.SpanContext.SpanID())
+	}
+
+	// The child gets the next span ID and points at its parent, not at itself
+	if childSpan.SpanContext.SpanID().String() != "00f067aa0ba902b8" {
+		t.Errorf("Expected the next span ID for the child, got %s", childSpan.SpanContext.SpanID())
+	}
+	if childSpan.Parent.SpanID() != parentSpan.SpanContext.SpanID() {
+		t.Errorf("Expected the child's parent to be %s, got %s", parentSpan.SpanContext.SpanID(), childSpan.Parent.SpanID())
+	}
+}
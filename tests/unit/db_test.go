@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartDBSpan(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), vayuOtel.GetTracerNameKey(), vayuOtel.GetDefaultTracerName())
+	ctx, parent := provider.TracerProvider.Tracer("test").Start(ctx, "parent")
+
+	longStatement := "SELECT * FROM users WHERE id IN (" + strings.Repeat("1,", vayuOtel.MaxDBStatementLength) + "1)"
+	dbSpan := vayuOtel.StartDBSpan(ctx, "postgresql", "SELECT", longStatement)
+	dbSpan.End()
+	parent.End()
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	var found bool
+	for _, span := range exporter.GetSpans() {
+		if span.Name != "postgresql SELECT" {
+			continue
+		}
+		found = true
+
+		if span.SpanKind != trace.SpanKindClient {
+			t.Errorf("Expected client span kind, got %v", span.SpanKind)
+		}
+
+		if v, _ := tests.FindAttribute(span.Attributes, "db.system"); v.AsString() != "postgresql" {
+			t.Errorf("Expected db.system=postgresql, got %q", v.AsString())
+		}
+
+		if v, _ := tests.FindAttribute(span.Attributes, "db.operation"); v.AsString() != "SELECT" {
+			t.Errorf("Expected db.operation=SELECT, got %q", v.AsString())
+		}
+
+		v, _ := tests.FindAttribute(span.Attributes, "db.statement")
+		if len(v.AsString()) != vayuOtel.MaxDBStatementLength {
+			t.Errorf("Expected db.statement to be truncated to %d bytes, got %d", vayuOtel.MaxDBStatementLength, len(v.AsString()))
+		}
+	}
+
+	if !found {
+		t.Fatal("Expected database span to be exported")
+	}
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(integration.UnaryServerInterceptor()))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial bufconn: %v", err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+
+	// Unknown services return NotFound, which should mark the span as an error
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Fatal("Expected health check for unknown service to fail")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := integration.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	for _, span := range spans {
+		if span.Name != "/grpc.health.v1.Health/Check" {
+			t.Errorf("Expected span name to be the full method, got %q", span.Name)
+		}
+
+		if span.SpanKind != trace.SpanKindServer {
+			t.Errorf("Expected server span kind, got %v", span.SpanKind)
+		}
+	}
+
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "rpc.grpc.status_code"); v.AsInt64() != 0 {
+		t.Errorf("Expected rpc.grpc.status_code=0, got %d", v.AsInt64())
+	}
+
+	if spans[1].Status.Code != codes.Error {
+		t.Errorf("Expected failed call span to have error status, got %v", spans[1].Status.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWrapHandler(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	var handlerSpan trace.SpanContext
+	handler := integration.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected wrapped handler's status 503, got %d", recorder.Code)
+	}
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "HTTP GET /static/app.js" {
+		t.Errorf("Expected span name 'HTTP GET /static/app.js', got %q", span.Name)
+	}
+
+	if span.SpanContext.SpanID() != handlerSpan.SpanID() {
+		t.Error("Expected the wrapped handler to receive the span in its request context")
+	}
+
+	if v, _ := tests.FindAttribute(span.Attributes, "http.status_code"); v.AsInt64() != http.StatusServiceUnavailable {
+		t.Errorf("Expected http.status_code=503, got %d", v.AsInt64())
+	}
+
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected error status for 503, got %v", span.Status.Code)
+	}
+}
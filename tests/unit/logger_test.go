@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// capturingLogger records the messages it receives
+type capturingLogger struct {
+	mu       sync.Mutex
+	warnings []string
+	errors   []string
+}
+
+func (l *capturingLogger) Warn(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, msg)
+}
+
+func (l *capturingLogger) Error(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, msg)
+}
+
+// partialDetector detects some attributes but reports that others were unavailable
+type partialDetector struct{}
+
+func (partialDetector) Detect(ctx context.Context) (*resource.Resource, error) {
+	return resource.NewSchemaless(attribute.String("host.name", "test-host")),
+		fmt.Errorf("%w: cloud metadata unavailable", resource.ErrPartialResource)
+}
+
+func TestLoggerPartialResourceDetection(t *testing.T) {
+	logger := &capturingLogger{}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+	cfg.Logger = logger
+	cfg.ResourceDetectors = []resource.Detector{partialDetector{}}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Expected partial resource detection not to fail provider creation, got %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	if len(logger.warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(logger.warnings))
+	}
+}
+
+func TestLoggerDefaultsToNoop(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+	cfg.ResourceDetectors = []resource.Detector{partialDetector{}}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Expected provider creation without a logger to succeed, got %v", err)
+	}
+	provider.Shutdown(context.Background())
+}
+
+func TestLoggerMetricInstrumentError(t *testing.T) {
+	logger := &capturingLogger{}
+
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter(), func(cfg *vayuOtel.Config) {
+		cfg.Logger = logger
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	// A histogram aggregation is incompatible with the active requests up-down counter
+	integration.Provider().MeterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewManualReader()),
+		sdkmetric.WithView(sdkmetric.NewView(
+			sdkmetric.Instrument{Name: "http.server.active_requests"},
+			sdkmetric.Stream{Aggregation: aggregation.ExplicitBucketHistogram{Boundaries: []float64{1}}},
+		)),
+	)
+	integration.MetricsMiddleware()
+
+	if len(logger.errors) != 1 {
+		t.Fatalf("Expected 1 error for the failed instrument, got %d", len(logger.errors))
+	}
+}
+
+// failingExporter rejects every export
+type failingExporter struct{}
+
+func (failingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return errors.New("collector unavailable")
+}
+
+func (failingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestLoggerExportFailure(t *testing.T) {
+	logger := &capturingLogger{}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = failingExporter{}
+	cfg.ExportMode = vayuOtel.ExportModeSimple
+	cfg.Logger = logger
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "work")
+	span.End()
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.errors) != 1 || logger.errors[0] != "failed to export spans" {
+		t.Errorf("Expected the failed export to be logged, got %v", logger.errors)
+	}
+}
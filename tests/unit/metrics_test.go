@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// setupMetricsIntegration creates an integration with the Prometheus metrics exporter enabled
+func setupMetricsIntegration(t *testing.T) *vayuOtel.Integration {
+	t.Helper()
+
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter(), func(cfg *vayuOtel.Config) {
+		cfg.MetricsExporter = vayuOtel.MetricsExporterPrometheus
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	t.Cleanup(func() {
+		integration.Shutdown(context.Background())
+	})
+	integration.RegisterRoutes("/users", "/panic")
+
+	return integration
+}
+
+// scrape returns the Prometheus handler output
+func scrape(t *testing.T, integration *vayuOtel.Integration) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	integration.PrometheusHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected scrape to return 200, got %d", recorder.Code)
+	}
+
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	integration := setupMetricsIntegration(t)
+
+	middleware := integration.MetricsMiddleware()
+	tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/users", nil), func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+
+	output := scrape(t, integration)
+
+	expected := `http_server_request_count_total{http_method="GET",http_route="/users",http_status_code="200"} 1`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected scrape output to contain %q, got:\n%s", expected, output)
+	}
+
+	if !strings.Contains(output, "# TYPE http_server_duration_milliseconds histogram") {
+		t.Errorf("Expected duration histogram in scrape output, got:\n%s", output)
+	}
+}
+
+func TestPrometheusHandlerNotConfigured(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter())
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	recorder := httptest.NewRecorder()
+	integration.PrometheusHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when metrics are disabled, got %d", recorder.Code)
+	}
+}
+
+func TestActiveRequestsGauge(t *testing.T) {
+	integration := setupMetricsIntegration(t)
+
+	expected := `http_server_active_requests{http_method="GET",http_route="/users"} `
+
+	var during string
+	middleware := integration.MetricsMiddleware()
+	tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/users", nil), func(c *vayu.Context) {
+		during = scrape(t, integration)
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+
+	if !strings.Contains(during, expected+"1") {
+		t.Errorf("Expected 1 active request during handling, got:\n%s", during)
+	}
+
+	if after := scrape(t, integration); !strings.Contains(after, expected+"0") {
+		t.Errorf("Expected 0 active requests after handling, got:\n%s", after)
+	}
+}
+
+func TestActiveRequestsGaugePanic(t *testing.T) {
+	integration := setupMetricsIntegration(t)
+
+	func() {
+		defer func() { recover() }()
+		tests.ServeMiddleware(integration.MetricsMiddleware(), httptest.NewRequest(http.MethodGet, "/panic", nil), func(c *vayu.Context) {
+			panic("handler failed")
+		})
+	}()
+
+	expected := `http_server_active_requests{http_method="GET",http_route="/panic"} 0`
+	if output := scrape(t, integration); !strings.Contains(output, expected) {
+		t.Errorf("Expected active requests to be decremented after a panic, got:\n%s", output)
+	}
+}
+
+func TestPrometheusHandlerOpenMetricsExemplars(t *testing.T) {
+	integration := setupMetricsIntegration(t)
+
+	// The tracing middleware runs inside the metrics middleware, so the sampled span
+	// is on the request context when the duration is recorded
+	tracing := integration.Middleware()
+	var traceID string
+	tests.ServeMiddleware(integration.MetricsMiddleware(), httptest.NewRequest(http.MethodGet, "/users", nil), func(c *vayu.Context) {
+		tracing(c, func() {
+			traceID = vayuOtel.ActiveSpan(c).SpanContext().TraceID().String()
+			c.Writer.WriteHeader(http.StatusOK)
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+	recorder := httptest.NewRecorder()
+	integration.PrometheusHandler().ServeHTTP(recorder, req)
+
+	if ct := recorder.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Expected OpenMetrics content type, got %q", ct)
+	}
+
+	output := recorder.Body.String()
+	if !strings.Contains(output, `trace_id="`+traceID+`"`) {
+		t.Errorf("Expected an exemplar for trace %s, got:\n%s", traceID, output)
+	}
+	if !strings.HasSuffix(output, "# EOF\n") {
+		t.Errorf("Expected OpenMetrics output to end with # EOF, got:\n%s", output)
+	}
+
+	// The plain text format carries no exemplars
+	if strings.Contains(scrape(t, integration), "trace_id=") {
+		t.Error("Expected no exemplars in the Prometheus text format")
+	}
+}
+
+func TestBodySizeHistograms(t *testing.T) {
+	integration := setupMetricsIntegration(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"ada"}`))
+	tests.ServeMiddleware(integration.MetricsMiddleware(), req, func(c *vayu.Context) {
+		c.Writer.Write([]byte("created"))
+	})
+
+	output := scrape(t, integration)
+
+	for _, expected := range []string{
+		`http_server_request_size_bytes_sum{http_method="POST",http_route="/users"} 14`,
+		`http_server_request_size_bytes_count{http_method="POST",http_route="/users"} 1`,
+		`http_server_response_size_bytes_sum{http_method="POST",http_route="/users"} 7`,
+		`http_server_response_size_bytes_count{http_method="POST",http_route="/users"} 1`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected scrape output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestMetricsUnmatchedRouteLabel(t *testing.T) {
+	integration := setupMetricsIntegration(t)
+
+	middleware := integration.MetricsMiddleware()
+	for _, path := range []string{"/missing/1", "/missing/2"} {
+		tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, path, nil), func(c *vayu.Context) {
+			c.Writer.WriteHeader(http.StatusNotFound)
+		})
+	}
+
+	output := scrape(t, integration)
+
+	// Paths matching no route share one series instead of leaking into the label
+	expected := `http_server_request_count_total{http_method="GET",http_route="[unmatched]",http_status_code="404"} 2`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected scrape output to contain %q, got:\n%s", expected, output)
+	}
+	if strings.Contains(output, "/missing") {
+		t.Errorf("Expected raw paths to be absent from labels, got:\n%s", output)
+	}
+}
+
+func TestMetricsMiddlewareExternalMeterProvider(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter())
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	// A meter provider supplied by the application has no exemplar reservoir
+	reader := sdkmetric.NewManualReader()
+	integration.Provider().MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	tracing := integration.Middleware()
+	tests.ServeMiddleware(integration.MetricsMiddleware(), httptest.NewRequest(http.MethodGet, "/users", nil), func(c *vayu.Context) {
+		tracing(c, func() {
+			c.Writer.WriteHeader(http.StatusOK)
+		})
+	})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Failed to collect metrics: %v", err)
+	}
+	if len(rm.ScopeMetrics) == 0 || len(rm.ScopeMetrics[0].Metrics) == 0 {
+		t.Error("Expected the request to be recorded by the external meter provider")
+	}
+}
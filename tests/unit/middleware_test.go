@@ -0,0 +1,1121 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serveAndCollect runs a single request through the middleware and returns the exported spans
+func serveAndCollect(t *testing.T, opts vayuOtel.MiddlewareOptions, req *http.Request, handler func(c *vayu.Context)) tracetest.SpanStubs {
+	t.Helper()
+
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	tests.ServeMiddleware(integration.Middleware(opts), req, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := integration.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	return exporter.GetSpans()
+}
+
+func TestMiddlewareErrorStatusPredicate(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.ErrorStatusPredicate = func(statusCode int) bool {
+		return statusCode >= 400
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusNotFound)
+	})
+
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected span status to be Error, got %v", span.Status.Code)
+	}
+
+	if v, ok := tests.FindAttribute(span.Attributes, "error"); !ok || !v.AsBool() {
+		t.Error("Expected error=true attribute on span")
+	}
+
+	if v, ok := tests.FindAttribute(span.Attributes, "http.status_code"); !ok || v.AsInt64() != http.StatusNotFound {
+		t.Errorf("Expected http.status_code=404, got %v", v.Emit())
+	}
+}
+
+func TestMiddlewareDefaultErrorStatusPredicate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusNotFound)
+	})
+
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if spans[0].Status.Code == codes.Error {
+		t.Error("Expected 404 not to be marked as error by default")
+	}
+}
+
+func TestMiddlewareCaptureRequestHeaders(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CaptureRequestHeaders = []string{"X-Request-ID", "X-Tenant"}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	spans := serveAndCollect(t, opts, req, nil)
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := spans[0].Attributes
+	if v, ok := tests.FindAttribute(attrs, "http.request.header.x_request_id"); !ok || v.AsStringSlice()[0] != "req-123" {
+		t.Errorf("Expected http.request.header.x_request_id=req-123, got %v", v.Emit())
+	}
+
+	if _, ok := tests.FindAttribute(attrs, "http.request.header.x_tenant"); ok {
+		t.Error("Expected absent X-Tenant header not to be captured")
+	}
+
+	if _, ok := tests.FindAttribute(attrs, "http.request.header.authorization"); ok {
+		t.Error("Expected non-allowlisted Authorization header not to be captured")
+	}
+}
+
+func TestMiddlewareClientIPAndProtocol(t *testing.T) {
+	testCases := []struct {
+		name       string
+		headers    map[string]string
+		remoteAddr string
+		protoMajor int
+		protoMinor int
+		expectedIP string
+		expectedPV string
+	}{
+		{
+			name:       "forwarded for",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1", "X-Real-IP": "10.0.0.2"},
+			remoteAddr: "10.0.0.3:1234",
+			protoMajor: 1,
+			protoMinor: 1,
+			expectedIP: "203.0.113.7",
+			expectedPV: "1.1",
+		},
+		{
+			name:       "real ip",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.4"},
+			remoteAddr: "10.0.0.3:1234",
+			protoMajor: 2,
+			protoMinor: 0,
+			expectedIP: "198.51.100.4",
+			expectedPV: "2",
+		},
+		{
+			name:       "direct",
+			remoteAddr: "192.0.2.10:5678",
+			protoMajor: 1,
+			protoMinor: 0,
+			expectedIP: "192.0.2.10",
+			expectedPV: "1.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.ProtoMajor = tc.protoMajor
+			req.ProtoMinor = tc.protoMinor
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, nil)
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+
+			attrs := spans[0].Attributes
+			if v, _ := tests.FindAttribute(attrs, "http.client_ip"); v.AsString() != tc.expectedIP {
+				t.Errorf("Expected http.client_ip=%s, got %s", tc.expectedIP, v.AsString())
+			}
+
+			if v, _ := tests.FindAttribute(attrs, "net.protocol.version"); v.AsString() != tc.expectedPV {
+				t.Errorf("Expected net.protocol.version=%s, got %s", tc.expectedPV, v.AsString())
+			}
+		})
+	}
+}
+
+func TestMiddlewareCaptureQueryParams(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CaptureQueryParams = true
+	opts.RedactQueryParams = []string{"token"}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=foo&token=secret&tag=a&tag=b", nil)
+	spans := serveAndCollect(t, opts, req, nil)
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	expected := map[string]string{
+		"http.query.q":     "foo",
+		"http.query.token": "[REDACTED]",
+		"http.query.tag":   "a,b",
+	}
+
+	for key, want := range expected {
+		if v, _ := tests.FindAttribute(spans[0].Attributes, key); v.AsString() != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, v.AsString())
+		}
+	}
+
+	// The URL attributes must not leak the redacted value either
+	for _, attr := range spans[0].Attributes {
+		if strings.Contains(attr.Value.Emit(), "secret") {
+			t.Errorf("Expected no attribute to contain the redacted value, got %s=%q", attr.Key, attr.Value.Emit())
+		}
+	}
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "http.url"); v.AsString() != "/search?q=foo&token=[REDACTED]&tag=a&tag=b" {
+		t.Errorf("Expected the redacted http.url, got %q", v.AsString())
+	}
+}
+
+func TestMiddlewareRedactQueryParamsStableURL(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.SemConvVersion = vayuOtel.SemConvBoth
+	opts.RedactQueryParams = []string{"token"}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=foo&Token=secret", nil)
+	spans := serveAndCollect(t, opts, req, nil)
+
+	for _, attr := range spans[0].Attributes {
+		if strings.Contains(attr.Value.Emit(), "secret") {
+			t.Errorf("Expected no attribute to contain the redacted value, got %s=%q", attr.Key, attr.Value.Emit())
+		}
+	}
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "url.query"); v.AsString() != "q=foo&Token=[REDACTED]" {
+		t.Errorf("Expected the redacted url.query, got %q", v.AsString())
+	}
+}
+
+func TestMiddlewareSemConvVersion(t *testing.T) {
+	legacy := []string{"http.method", "http.url", "http.target", "http.status_code"}
+	stable := []string{"http.request.method", "url.full", "url.path", "server.address", "server.port", "http.response.status_code"}
+
+	testCases := []struct {
+		version      string
+		expectLegacy bool
+		expectStable bool
+	}{
+		{vayuOtel.SemConvLegacy, true, false},
+		{vayuOtel.SemConvStable, false, true},
+		{vayuOtel.SemConvBoth, true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			opts := vayuOtel.DefaultMiddlewareOptions()
+			opts.SemConvVersion = tc.version
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com:8080/users?page=2", nil)
+			spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+				c.Writer.WriteHeader(http.StatusCreated)
+			})
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+
+			attrs := spans[0].Attributes
+			for _, key := range legacy {
+				if _, ok := tests.FindAttribute(attrs, key); ok != tc.expectLegacy {
+					t.Errorf("Expected legacy attribute %s present=%v", key, tc.expectLegacy)
+				}
+			}
+			for _, key := range stable {
+				if _, ok := tests.FindAttribute(attrs, key); ok != tc.expectStable {
+					t.Errorf("Expected stable attribute %s present=%v", key, tc.expectStable)
+				}
+			}
+
+			if tc.expectStable {
+				if v, _ := tests.FindAttribute(attrs, "http.response.status_code"); v.AsInt64() != http.StatusCreated {
+					t.Errorf("Expected http.response.status_code=201, got %d", v.AsInt64())
+				}
+				if v, _ := tests.FindAttribute(attrs, "server.address"); v.AsString() != "example.com" {
+					t.Errorf("Expected server.address=example.com, got %q", v.AsString())
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareErrorExtractor(t *testing.T) {
+	type ctxKey struct{}
+	businessErr := errors.New("insufficient funds")
+
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.ErrorExtractor = func(c *vayu.Context) error {
+		err, _ := c.Request.Context().Value(ctxKey{}).(error)
+		return err
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+		// Handler reports a business error but still responds with 200
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKey{}, businessErr))
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Status.Code != codes.Error || span.Status.Description != businessErr.Error() {
+		t.Errorf("Expected error status %q, got %v %q", businessErr.Error(), span.Status.Code, span.Status.Description)
+	}
+
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Errorf("Expected the error to be recorded as an exception event, got %v", span.Events)
+	}
+}
+
+func TestMiddlewareInjectTraceHeader(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.InjectTraceHeader = true
+
+	req := httptest.NewRequest(http.MethodGet, "/support", nil)
+	recorder := tests.ServeMiddleware(integration.Middleware(opts), req, func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	expected := spans[0].SpanContext.TraceID().String()
+	if got := recorder.Header().Get(vayuOtel.TraceIDHeader); got != expected {
+		t.Errorf("Expected %s header %q, got %q", vayuOtel.TraceIDHeader, expected, got)
+	}
+}
+
+func TestMiddlewareTraceHeaderDisabledByDefault(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	req := httptest.NewRequest(http.MethodGet, "/support", nil)
+	recorder := tests.ServeMiddleware(integration.Middleware(), req, nil)
+
+	if got := recorder.Header().Get(vayuOtel.TraceIDHeader); got != "" {
+		t.Errorf("Expected no %s header by default, got %q", vayuOtel.TraceIDHeader, got)
+	}
+}
+
+func TestMiddlewareHeaderAttributes(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CustomAttributes = vayuOtel.CombineAttributeFuncs(
+		vayuOtel.WithHeaderAttribute("X-Tenant-ID", "tenant.id"),
+		vayuOtel.WithHeaderAttribute("X-Region", "tenant.region"),
+		vayuOtel.WithHeaderAttribute("X-Missing", "tenant.missing"),
+	)
+
+	for _, tenant := range []string{"acme", "globex"} {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		req.Header.Set("X-Region", "eu-west-1")
+
+		spans := serveAndCollect(t, opts, req, nil)
+		if len(spans) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(spans))
+		}
+
+		// CustomAttributes must be evaluated for each request, not once at setup
+		if v, _ := tests.FindAttribute(spans[0].Attributes, "tenant.id"); v.AsString() != tenant {
+			t.Errorf("Expected tenant.id=%s, got %q", tenant, v.AsString())
+		}
+
+		if v, _ := tests.FindAttribute(spans[0].Attributes, "tenant.region"); v.AsString() != "eu-west-1" {
+			t.Errorf("Expected tenant.region=eu-west-1, got %q", v.AsString())
+		}
+
+		if _, ok := tests.FindAttribute(spans[0].Attributes, "tenant.missing"); ok {
+			t.Error("Expected absent header to be skipped")
+		}
+	}
+}
+
+func TestMiddlewareResponseErrorDetector(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.ResponseBodyCaptureLimit = 16
+	opts.ResponseErrorDetector = func(statusCode int, body []byte) bool {
+		return bytes.HasPrefix(body, []byte(`{"error":`))
+	}
+
+	testCases := []struct {
+		name      string
+		body      string
+		wantError bool
+	}{
+		{"error envelope", `{"error": "legacy failure with a long message"}`, true},
+		{"success body", `{"data": "ok"}`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var written int
+			req := httptest.NewRequest(http.MethodGet, "/legacy", nil)
+			spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+				c.Writer.WriteHeader(http.StatusOK)
+				written, _ = c.Writer.Write([]byte(tc.body))
+			})
+
+			if written != len(tc.body) {
+				t.Errorf("Expected the full body to be written, got %d of %d bytes", written, len(tc.body))
+			}
+
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+
+			if got := spans[0].Status.Code == codes.Error; got != tc.wantError {
+				t.Errorf("Expected error status %v, got %v", tc.wantError, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareInstrumentationScope(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+		cfg.InstrumentationName = "acme-http"
+		cfg.InstrumentationVersion = "1.2.3"
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/scoped", nil)
+	tests.ServeMiddleware(integration.Middleware(), req, func(c *vayu.Context) {
+		vayuOtel.Start(c.Request.Context(), "child").End()
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	for _, span := range spans {
+		scope := span.InstrumentationLibrary
+		if scope.Name != "acme-http" || scope.Version != "1.2.3" {
+			t.Errorf("Expected span %q to have scope acme-http/1.2.3, got %s/%s", span.Name, scope.Name, scope.Version)
+		}
+	}
+}
+
+func TestMiddlewareSanitizeSpanName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		patterns []vayuOtel.PathSegmentPattern
+		expected string
+	}{
+		{"numeric ID", "/orders/42", nil, "HTTP GET /orders/{id}"},
+		{"UUID", "/orders/8f3a1c2e-5b6d-4e7f-9a0b-1c2d3e4f5a6b", nil, "HTTP GET /orders/{uuid}"},
+		{"mixed path", "/users/7/orders/8F3A1C2E-5B6D-4E7F-9A0B-1C2D3E4F5A6B/items", nil, "HTTP GET /users/{id}/orders/{uuid}/items"},
+		{"static path", "/health", nil, "HTTP GET /health"},
+		{
+			"custom pattern",
+			"/products/sku-123/reviews/5",
+			[]vayuOtel.PathSegmentPattern{{Pattern: regexp.MustCompile(`^sku-[0-9]+$`), Replacement: "{sku}"}},
+			"HTTP GET /products/{sku}/reviews/5",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := vayuOtel.DefaultMiddlewareOptions()
+			opts.SanitizeSpanName = true
+			opts.PathSegmentPatterns = tc.patterns
+
+			spans := serveAndCollect(t, opts, httptest.NewRequest(http.MethodGet, tc.path, nil), nil)
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+
+			if spans[0].Name != tc.expected {
+				t.Errorf("Expected span name %q, got %q", tc.expected, spans[0].Name)
+			}
+		})
+	}
+}
+
+func TestMiddlewareHandlerDuration(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.Clock = clock
+
+	c := &vayu.Context{
+		Request: httptest.NewRequest(http.MethodGet, "/slow", nil),
+		Writer:  httptest.NewRecorder(),
+		Params:  make(map[string]string),
+	}
+	integration.Middleware(opts)(c, func() {
+		clock.now = clock.now.Add(5 * time.Millisecond)
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	// The duration is available to middleware running outside the tracing middleware
+	stored, ok := vayuOtel.RequestDuration(c)
+	if !ok || stored != 5*time.Millisecond {
+		t.Errorf("Expected stored request duration of 5ms, got %v", stored)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	v, ok := tests.FindAttribute(spans[0].Attributes, "http.server.duration_ms")
+	if !ok || v.AsFloat64() != 5 {
+		t.Errorf("Expected http.server.duration_ms=5, got %v", v.AsFloat64())
+	}
+}
+
+func TestMiddlewareClientDisconnected(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(reqCtx)
+
+	// The client goes away while the handler is running, and the handler gives up
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {
+		cancel()
+		c.Writer.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Status.Code != codes.Unset {
+		t.Errorf("Expected status Unset for a cancelled request, got %v", span.Status.Code)
+	}
+	if v, ok := tests.FindAttribute(span.Attributes, "http.client_disconnected"); !ok || !v.AsBool() {
+		t.Error("Expected http.client_disconnected=true")
+	}
+	if _, ok := tests.FindAttribute(span.Attributes, "error"); ok {
+		t.Error("Expected no error attribute for a cancelled request")
+	}
+}
+
+func TestCombineAttributeFuncs(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CustomAttributes = vayuOtel.CombineAttributeFuncs(
+		func(c *vayu.Context) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.String("auth.user", "alice")}
+		},
+		func(c *vayu.Context) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.String("tenant.id", "acme")}
+		},
+		func(c *vayu.Context) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.Bool("feature.new_checkout", true)}
+		},
+	)
+
+	spans := serveAndCollect(t, opts, httptest.NewRequest(http.MethodGet, "/checkout", nil), nil)
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	for _, key := range []string{"auth.user", "tenant.id", "feature.new_checkout"} {
+		if _, ok := tests.FindAttribute(spans[0].Attributes, key); !ok {
+			t.Errorf("Expected attribute %s to be set", key)
+		}
+	}
+}
+
+func TestMiddlewarePreservesTraceState(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=hint,other=value")
+
+	var handlerState string
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {
+		handlerState = vayuOtel.ActiveSpan(c).TraceState().String()
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	state := spans[0].SpanContext.TraceState()
+	if state.Get("vendor") != "hint" || state.Get("other") != "value" {
+		t.Errorf("Expected incoming tracestate to be preserved, got %q", state.String())
+	}
+	if handlerState != state.String() {
+		t.Errorf("Expected the handler to see trace state %q, got %q", state.String(), handlerState)
+	}
+}
+
+func TestAddServerSpanAttributes(t *testing.T) {
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), httptest.NewRequest(http.MethodGet, "/profile", nil), func(c *vayu.Context) {
+		// Attributes set while a child span is running still land on the server span
+		child := vayuOtel.Start(c.Request.Context(), "load-user")
+		vayuOtel.AddServerSpanAttributes(c, map[string]interface{}{
+			"user.id":   "42",
+			"cache.hit": true,
+		})
+		child.End()
+	})
+
+	server := findSpan(t, spans, "HTTP GET /profile")
+	if v, _ := tests.FindAttribute(server.Attributes, "user.id"); v.AsString() != "42" {
+		t.Errorf("Expected user.id=42 on the server span, got %q", v.AsString())
+	}
+	if v, ok := tests.FindAttribute(server.Attributes, "cache.hit"); !ok || !v.AsBool() {
+		t.Error("Expected cache.hit=true on the server span")
+	}
+	if _, ok := tests.FindAttribute(findSpan(t, spans, "load-user").Attributes, "user.id"); ok {
+		t.Error("Expected the child span not to receive server span attributes")
+	}
+}
+
+func TestMiddlewareDisableDefaultAttributes(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.DisableDefaultAttributes = true
+	opts.CustomAttributes = func(c *vayu.Context) []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.String("tenant.id", "acme")}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	spans := serveAndCollect(t, opts, req, nil)
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := spans[0].Attributes
+	if len(attrs) != 1 || attrs[0].Key != "tenant.id" {
+		t.Errorf("Expected only the custom attribute, got %v", attrs)
+	}
+}
+
+func TestMiddlewareAttributeSelector(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.AttributeSelector = func(name string) bool {
+		return name != "http.user_agent" && !strings.HasPrefix(name, "http.route.param.")
+	}
+
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	c := &vayu.Context{Request: req, Writer: httptest.NewRecorder(), Params: map[string]string{"id": "42"}}
+	integration.Middleware(opts)(c, func() {})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	attrs := exporter.GetSpans()[0].Attributes
+	if _, ok := tests.FindAttribute(attrs, "http.user_agent"); ok {
+		t.Error("Expected http.user_agent to be dropped")
+	}
+	if _, ok := tests.FindAttribute(attrs, "http.route.param.id"); ok {
+		t.Error("Expected route params to be dropped")
+	}
+	if _, ok := tests.FindAttribute(attrs, "http.method"); !ok {
+		t.Error("Expected http.method to be kept")
+	}
+}
+
+// fakeClock is a Clock that only moves when advanced
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestMiddlewareClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.Clock = clock
+
+	spans := serveAndCollect(t, opts, httptest.NewRequest(http.MethodGet, "/slow", nil), func(c *vayu.Context) {
+		clock.now = clock.now.Add(1500 * time.Millisecond)
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "http.server.duration_ms"); v.AsFloat64() != 1500 {
+		t.Errorf("Expected http.server.duration_ms=1500, got %v", v.AsFloat64())
+	}
+	if d := spans[0].EndTime.Sub(spans[0].StartTime); d != 1500*time.Millisecond {
+		t.Errorf("Expected span duration 1.5s, got %s", d)
+	}
+}
+
+func TestMiddlewareEncodingAttributes(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/upload", nil)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {
+		c.Writer.Header().Set("Content-Encoding", "br")
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "http.request.encoding"); v.AsString() != "gzip" {
+		t.Errorf("Expected http.request.encoding=gzip, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "http.response.encoding"); v.AsString() != "br" {
+		t.Errorf("Expected http.response.encoding=br, got %q", v.AsString())
+	}
+
+	// Absent headers are not recorded
+	spans = serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), httptest.NewRequest(http.MethodGet, "/plain", nil), nil)
+	if _, ok := tests.FindAttribute(spans[0].Attributes, "http.request.encoding"); ok {
+		t.Error("Expected no http.request.encoding without a Content-Encoding header")
+	}
+	if _, ok := tests.FindAttribute(spans[0].Attributes, "http.response.encoding"); ok {
+		t.Error("Expected no http.response.encoding without a Content-Encoding header")
+	}
+}
+
+func TestMiddlewareStatusClass(t *testing.T) {
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), httptest.NewRequest(http.MethodGet, "/users/42", nil), func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "http.status_class"); v.AsString() != "5xx" {
+		t.Errorf("Expected http.status_class=5xx, got %q", v.AsString())
+	}
+}
+
+func TestMiddlewareLifecycleEvents(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.EmitLifecycleEvents = true
+
+	spans := serveAndCollect(t, opts, httptest.NewRequest(http.MethodDelete, "/orders/7", nil), func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusNoContent)
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	events := spans[0].Events
+	if len(events) != 2 || events[0].Name != "request.start" || events[1].Name != "request.end" {
+		t.Fatalf("Expected request.start and request.end events, got %v", events)
+	}
+	if v, _ := tests.FindAttribute(events[0].Attributes, "http.method"); v.AsString() != http.MethodDelete {
+		t.Errorf("Expected request.start http.method=DELETE, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(events[1].Attributes, "http.status_code"); v.AsInt64() != http.StatusNoContent {
+		t.Errorf("Expected request.end http.status_code=204, got %d", v.AsInt64())
+	}
+	if events[1].Time.Before(events[0].Time) {
+		t.Error("Expected request.end not to precede request.start")
+	}
+
+	// Events are off by default
+	spans = serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), httptest.NewRequest(http.MethodGet, "/orders", nil), nil)
+	if len(spans[0].Events) != 0 {
+		t.Errorf("Expected no lifecycle events by default, got %d", len(spans[0].Events))
+	}
+}
+
+func TestMiddlewareCaptureRequestBody(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CaptureRequestBody = &vayuOtel.RequestBodyCapture{MaxBodyBytes: 16}
+
+	payload := `{"event":"invoice.paid","id":"in_123"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var handlerBody string
+	spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		handlerBody = string(body)
+	})
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	// The handler must still read the full body
+	if handlerBody != payload {
+		t.Errorf("Expected the handler to read %q, got %q", payload, handlerBody)
+	}
+
+	events := spans[0].Events
+	if len(events) != 1 || events[0].Name != "request.body" {
+		t.Fatalf("Expected a request.body event, got %v", events)
+	}
+	if v, _ := tests.FindAttribute(events[0].Attributes, "http.request.body"); v.AsString() != payload[:16] {
+		t.Errorf("Expected the body truncated to 16 bytes, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(events[0].Attributes, "http.request.body.truncated"); !v.AsBool() {
+		t.Error("Expected http.request.body.truncated=true")
+	}
+
+	// Other content types are not captured
+	form := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("password=secret"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	spans = serveAndCollect(t, opts, form, nil)
+	if len(spans[0].Events) != 0 {
+		t.Error("Expected bodies of other content types not to be captured")
+	}
+}
+
+func TestMiddlewareBaggageToAttributes(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.BaggageToAttributes = []string{"tenant", "tier", "missing"}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("baggage", "tenant=acme,tier=gold,session=abc")
+
+	spans := serveAndCollect(t, opts, req, nil)
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := spans[0].Attributes
+	if v, _ := tests.FindAttribute(attrs, "baggage.tenant"); v.AsString() != "acme" {
+		t.Errorf("Expected baggage.tenant=acme, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(attrs, "baggage.tier"); v.AsString() != "gold" {
+		t.Errorf("Expected baggage.tier=gold, got %q", v.AsString())
+	}
+	if _, ok := tests.FindAttribute(attrs, "baggage.session"); ok {
+		t.Error("Expected unlisted baggage keys to be skipped")
+	}
+	if _, ok := tests.FindAttribute(attrs, "baggage.missing"); ok {
+		t.Error("Expected absent baggage keys to be skipped")
+	}
+}
+
+func TestMiddlewareHTTPRoute(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	integration.RegisterRoutes("/users/:id/orders/:orderId", "/:name/users", "/users/users", "/users/:id")
+
+	for path, expected := range map[string]string{
+		"/users/42":         "/users/:id",
+		"/users/5/orders/5": "/users/:id/orders/:orderId",
+		"/users/users":      "/users/users",
+		"/wp-admin/x.php":   "",
+	} {
+		// Repeat to catch results that depend on map iteration order
+		for i := 0; i < 20; i++ {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			tests.ServeMiddleware(integration.Middleware(), req, nil)
+		}
+
+		if err := integration.Provider().ForceFlush(context.Background()); err != nil {
+			t.Fatalf("Failed to flush spans: %v", err)
+		}
+		for _, span := range exporter.GetSpans() {
+			v, ok := tests.FindAttribute(span.Attributes, "http.route")
+			if expected == "" && ok {
+				t.Errorf("%s: expected no http.route for an unregistered path, got %q", path, v.AsString())
+			}
+			if expected != "" && v.AsString() != expected {
+				t.Errorf("%s: expected http.route=%s, got %q", path, expected, v.AsString())
+			}
+		}
+		exporter.Reset()
+	}
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+}
+
+func TestMiddlewareRouteParamOptions(t *testing.T) {
+	serve := func(opts vayuOtel.MiddlewareOptions) []attribute.KeyValue {
+		exporter := tests.NewInMemoryExporter()
+		integration, err := tests.SetupTestIntegration(exporter)
+		if err != nil {
+			t.Fatalf("Failed to set up integration: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/orgs/acme/users/42", nil)
+		c := &vayu.Context{Request: req, Writer: httptest.NewRecorder(), Params: map[string]string{"org": "acme", "id": "42"}}
+		integration.Middleware(opts)(c, func() {})
+
+		if err := integration.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Failed to shut down integration: %v", err)
+		}
+		return exporter.GetSpans()[0].Attributes
+	}
+
+	// Custom prefix
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.RouteParamPrefix = "route_param_"
+	attrs := serve(opts)
+	if v, _ := tests.FindAttribute(attrs, "route_param_id"); v.AsString() != "42" {
+		t.Errorf("Expected route_param_id=42, got %q", v.AsString())
+	}
+	if _, ok := tests.FindAttribute(attrs, "http.route.param.id"); ok {
+		t.Error("Expected the default prefix not to be used")
+	}
+
+	// Denylist suppresses high-cardinality params
+	opts = vayuOtel.DefaultMiddlewareOptions()
+	opts.RouteParamDenylist = []string{"id"}
+	attrs = serve(opts)
+	if _, ok := tests.FindAttribute(attrs, "http.route.param.id"); ok {
+		t.Error("Expected the denied param to be suppressed")
+	}
+	if _, ok := tests.FindAttribute(attrs, "http.route.param.org"); !ok {
+		t.Error("Expected other params to be kept")
+	}
+
+	// Allowlist keeps only the listed params
+	opts = vayuOtel.DefaultMiddlewareOptions()
+	opts.RouteParamAllowlist = []string{"id"}
+	attrs = serve(opts)
+	if _, ok := tests.FindAttribute(attrs, "http.route.param.org"); ok {
+		t.Error("Expected params outside the allowlist to be suppressed")
+	}
+	if _, ok := tests.FindAttribute(attrs, "http.route.param.id"); !ok {
+		t.Error("Expected the allowed param to be kept")
+	}
+}
+
+func TestMiddlewareGRPCStatusMapper(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.GRPCStatusMapper = vayuOtel.DefaultGRPCStatusMapper
+
+	for httpStatus, grpcStatus := range map[int]int64{200: 0, 404: 5, 500: 13} {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+			c.Writer.WriteHeader(httpStatus)
+		})
+
+		v, ok := tests.FindAttribute(spans[0].Attributes, "rpc.grpc.status_code")
+		if !ok || v.AsInt64() != grpcStatus {
+			t.Errorf("Expected rpc.grpc.status_code=%d for HTTP %d, got %v", grpcStatus, httpStatus, v.AsInt64())
+		}
+	}
+
+	// Without a mapper no gRPC status is recorded
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {})
+	if _, ok := tests.FindAttribute(spans[0].Attributes, "rpc.grpc.status_code"); ok {
+		t.Error("Expected no rpc.grpc.status_code attribute without a mapper")
+	}
+}
+
+func TestMiddlewareCaptureResponseContentType(t *testing.T) {
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CaptureResponseContentType = true
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	spans := serveAndCollect(t, opts, req, func(c *vayu.Context) {
+		c.JSON(http.StatusOK, map[string]string{"name": "ada"})
+	})
+	if v, _ := tests.FindAttribute(spans[0].Attributes, "http.response.content_type"); v.AsString() != "application/json" {
+		t.Errorf("Expected http.response.content_type=application/json, got %q", v.AsString())
+	}
+
+	// No attribute is recorded when the handler sets no Content-Type
+	req = httptest.NewRequest(http.MethodGet, "/empty", nil)
+	spans = serveAndCollect(t, opts, req, func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusNoContent)
+	})
+	if _, ok := tests.FindAttribute(spans[0].Attributes, "http.response.content_type"); ok {
+		t.Error("Expected no http.response.content_type attribute without a Content-Type header")
+	}
+}
+
+// slowFlushWriter is a response writer whose Flush takes a second on the given clock
+type slowFlushWriter struct {
+	*httptest.ResponseRecorder
+	clock *fakeClock
+}
+
+func (w slowFlushWriter) Flush() {
+	w.clock.now = w.clock.now.Add(time.Second)
+	w.ResponseRecorder.Flush()
+}
+
+func TestMiddlewareSpanEndTiming(t *testing.T) {
+	for _, tc := range []struct {
+		timing   string
+		duration time.Duration
+	}{
+		{vayuOtel.SpanEndAfterNext, 100 * time.Millisecond},
+		{vayuOtel.SpanEndAfterFlush, 1100 * time.Millisecond},
+	} {
+		clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+		opts := vayuOtel.DefaultMiddlewareOptions()
+		opts.Clock = clock
+		opts.SpanEndTiming = tc.timing
+
+		exporter := tests.NewInMemoryExporter()
+		integration, err := tests.SetupTestIntegration(exporter)
+		if err != nil {
+			t.Fatalf("Failed to set up integration: %v", err)
+		}
+
+		writer := slowFlushWriter{ResponseRecorder: httptest.NewRecorder(), clock: clock}
+		c := &vayu.Context{Request: httptest.NewRequest(http.MethodGet, "/download", nil), Writer: writer}
+		integration.Middleware(opts)(c, func() {
+			c.Writer.Write([]byte("payload"))
+			clock.now = clock.now.Add(100 * time.Millisecond)
+		})
+
+		if err := integration.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Failed to shut down integration: %v", err)
+		}
+
+		span := exporter.GetSpans()[0]
+		if d := span.EndTime.Sub(span.StartTime); d != tc.duration {
+			t.Errorf("%s: expected span duration %v, got %v", tc.timing, tc.duration, d)
+		}
+		if tc.timing == vayuOtel.SpanEndAfterFlush && !writer.Flushed {
+			t.Errorf("%s: expected the response to be flushed", tc.timing)
+		}
+	}
+}
+
+func TestMiddlewareExposeSamplingHeader(t *testing.T) {
+	for _, tc := range []struct {
+		sampler  sdktrace.Sampler
+		expected string
+	}{
+		{sdktrace.NeverSample(), "false"},
+		{sdktrace.AlwaysSample(), "true"},
+	} {
+		integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter(), func(cfg *vayuOtel.Config) {
+			cfg.Sampler = tc.sampler
+		})
+		if err != nil {
+			t.Fatalf("Failed to set up integration: %v", err)
+		}
+
+		opts := vayuOtel.DefaultMiddlewareOptions()
+		opts.ExposeSamplingHeader = true
+		recorder := tests.ServeMiddleware(integration.Middleware(opts), httptest.NewRequest(http.MethodGet, "/users", nil), nil)
+
+		if got := recorder.Header().Get(vayuOtel.TraceSampledHeader); got != tc.expected {
+			t.Errorf("%s: expected %s=%s, got %q", tc.sampler.Description(), vayuOtel.TraceSampledHeader, tc.expected, got)
+		}
+		integration.Shutdown(context.Background())
+	}
+}
+
+func TestMiddlewareUnsampledActiveSpan(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter(), func(cfg *vayuOtel.Config) {
+		cfg.Sampler = sdktrace.NeverSample()
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	// Unsampled requests carry a non-recording span, which handlers must still be able to read
+	var out bytes.Buffer
+	var traceID string
+	handler := integration.TraceHandler("/users", func(c *vayu.Context, next vayu.NextFunc) {
+		traceID = vayuOtel.GetTraceID(c)
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+	tracing := integration.Middleware()
+	tests.ServeMiddleware(vayuOtel.TracedLogger(&out), httptest.NewRequest(http.MethodGet, "/users", nil), func(c *vayu.Context) {
+		tracing(c, func() {
+			handler(c, func() {})
+		})
+	})
+
+	if traceID == "" {
+		t.Error("Expected the unsampled request to have a trace ID")
+	}
+	if !strings.Contains(out.String(), "trace_id="+traceID) {
+		t.Errorf("Expected the access log to carry trace_id=%s, got %q", traceID, out.String())
+	}
+}
+
+func TestMiddlewareServerSpanKind(t *testing.T) {
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), httptest.NewRequest(http.MethodGet, "/users", nil), func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+
+	if span := findSpan(t, spans, "HTTP GET /users"); span.SpanKind != trace.SpanKindServer {
+		t.Errorf("Expected server span kind, got %v", span.SpanKind)
+	}
+}
@@ -3,12 +3,15 @@ package unit
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/kaushiksamanta/vayu"
 	vayuOtel "github.com/kaushiksamanta/vayu-otel"
 	"github.com/kaushiksamanta/vayu-otel/tests"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -54,6 +57,47 @@ func TestSetup(t *testing.T) {
 	}
 }
 
+func TestIntegrationProviderAccessors(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter(), func(cfg *vayuOtel.Config) {
+		cfg.ServiceName = "accessor-service"
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	provider := integration.Provider()
+	if provider == nil {
+		t.Fatal("Expected provider to be non-nil")
+	}
+
+	if provider.Config.ServiceName != "accessor-service" {
+		t.Errorf("Expected provider config service name 'accessor-service', got %q", provider.Config.ServiceName)
+	}
+
+	if integration.TracerProvider() != provider.TracerProvider {
+		t.Error("Expected TracerProvider to return the provider's SDK tracer provider")
+	}
+}
+
+func TestIntegrationTracerProviderDisabled(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter(), func(cfg *vayuOtel.Config) {
+		cfg.Disabled = true
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	if integration.TracerProvider() == nil {
+		t.Fatal("Expected a no-op tracer provider when disabled")
+	}
+
+	_, span := integration.TracerProvider().Tracer("test").Start(context.Background(), "noop")
+	if span.IsRecording() {
+		t.Error("Expected span from disabled integration not to be recording")
+	}
+}
+
 func TestStart(t *testing.T) {
 	// Test the Start helper function
 	ctx := context.Background()
@@ -234,3 +278,182 @@ func TestSpanHierarchy(t *testing.T) {
 	// This test just verifies that the API works without errors
 	// The actual span hierarchy is verified by the OpenTelemetry SDK
 }
+
+func TestActiveSpan(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	var active *vayuOtel.Span
+	var requestSpan trace.Span
+	req := httptest.NewRequest(http.MethodGet, "/active", nil)
+	tests.ServeMiddleware(integration.Middleware(), req, func(c *vayu.Context) {
+		active = vayuOtel.ActiveSpan(c)
+		requestSpan = trace.SpanFromContext(c.Request.Context())
+	})
+
+	if active == nil {
+		t.Fatal("Expected active span wrapper to be non-nil")
+	}
+
+	if active.Span != requestSpan {
+		t.Error("Expected active span to be the middleware's server span")
+	}
+}
+
+func TestActiveSpanWithoutSpan(t *testing.T) {
+	c := &vayu.Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+
+	span := vayuOtel.ActiveSpan(c)
+	if span == nil {
+		t.Fatal("Expected active span wrapper to be non-nil")
+	}
+
+	if span.Span.IsRecording() {
+		t.Error("Expected no-op span when no span is active")
+	}
+
+	// All fluent methods must be safe on the no-op span
+	span.AddAttributes(map[string]interface{}{"key": "value"}).
+		AddEvent("event").
+		RecordError(errors.New("test error")).
+		End()
+
+	// A nil context must also be safe
+	vayuOtel.ActiveSpan(nil).End()
+}
+
+func TestGetTraceID(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	var traceID, spanID string
+	tests.ServeMiddleware(integration.Middleware(), httptest.NewRequest(http.MethodGet, "/ids", nil), func(c *vayu.Context) {
+		traceID = vayuOtel.GetTraceID(c)
+		spanID = vayuOtel.GetSpanID(c)
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	if traceID == "" || spanID == "" {
+		t.Fatal("Expected trace and span IDs to be available in the handler")
+	}
+
+	sc := exporter.GetSpans()[0].SpanContext
+	if traceID != sc.TraceID().String() || spanID != sc.SpanID().String() {
+		t.Errorf("Expected IDs %s/%s, got %s/%s", sc.TraceID(), sc.SpanID(), traceID, spanID)
+	}
+
+	// Without the middleware there are no IDs
+	if id := vayuOtel.GetTraceID(&vayu.Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}); id != "" {
+		t.Errorf("Expected no trace ID without an active span, got %q", id)
+	}
+}
+
+func TestIntegrationBackgroundContext(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	span := vayuOtel.Start(integration.BackgroundContext(), "nightly-job")
+	if !span.IsRecording() {
+		t.Error("Expected a recording root span from the background context")
+	}
+	vayuOtel.Start(span.Context(), "step").End()
+	span.End()
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	for _, s := range spans {
+		if s.InstrumentationLibrary.Name != vayuOtel.GetDefaultTracerName() {
+			t.Errorf("Expected span %q to use tracer %q, got %q", s.Name, vayuOtel.GetDefaultTracerName(), s.InstrumentationLibrary.Name)
+		}
+	}
+}
+
+// blockingExporter simulates an unreachable collector: exports block until released
+type blockingExporter struct {
+	release chan struct{}
+}
+
+func (e *blockingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	select {
+	case <-e.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *blockingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestIntegrationShutdownWithTimeout(t *testing.T) {
+	exporter := &blockingExporter{release: make(chan struct{})}
+	defer close(exporter.release)
+
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	_, span := integration.TracerProvider().Tracer("test").Start(context.Background(), "pending")
+	span.End()
+
+	start := time.Now()
+	err = integration.ShutdownWithTimeout(100 * time.Millisecond)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline exceeded error, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected shutdown to give up after the timeout, took %s", elapsed)
+	}
+}
+
+func TestIntegrationHealthHandler(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter())
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	probe := func() int {
+		recorder := httptest.NewRecorder()
+		integration.HealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return recorder.Code
+	}
+
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before the first export, got %d", code)
+	}
+
+	_, span := integration.TracerProvider().Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := integration.Provider().ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush provider: %v", err)
+	}
+
+	if code := probe(); code != http.StatusOK {
+		t.Errorf("Expected 200 after a successful export, got %d", code)
+	}
+}
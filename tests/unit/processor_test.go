@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSlowRequestThreshold(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.Sampler = sdktrace.NeverSample()
+	cfg.SlowRequestThreshold = 100 * time.Millisecond
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	tracer := provider.TracerProvider.Tracer("test")
+	start := time.Now()
+
+	_, fast := tracer.Start(context.Background(), "fast", trace.WithTimestamp(start))
+	fast.End(trace.WithTimestamp(start.Add(10 * time.Millisecond)))
+
+	_, slow := tracer.Start(context.Background(), "slow", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(250 * time.Millisecond)))
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush provider: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected only the slow span to be exported, got %d spans", len(spans))
+	}
+
+	if spans[0].Name != "slow" {
+		t.Errorf("Expected slow span to be exported, got %q", spans[0].Name)
+	}
+}
+
+// countingProcessor counts the spans it observes
+type countingProcessor struct {
+	started, ended, shutdown atomic.Int32
+}
+
+func (p *countingProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.started.Add(1)
+}
+
+func (p *countingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.ended.Add(1)
+}
+
+func (p *countingProcessor) Shutdown(ctx context.Context) error {
+	p.shutdown.Add(1)
+	return nil
+}
+
+func (p *countingProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func TestExtraSpanProcessors(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	counter := &countingProcessor{}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.ExtraSpanProcessors = []sdktrace.SpanProcessor{counter}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	tracer := provider.TracerProvider.Tracer("test")
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "work")
+		span.End()
+	}
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	if counter.started.Load() != 3 || counter.ended.Load() != 3 {
+		t.Errorf("Expected 3 started and 3 ended spans, got %d and %d", counter.started.Load(), counter.ended.Load())
+	}
+	if counter.shutdown.Load() != 1 {
+		t.Errorf("Expected the processor to be shut down once, got %d", counter.shutdown.Load())
+	}
+
+	// The exporting processor still runs alongside the extra one
+	if got := len(exporter.GetSpans()); got != 3 {
+		t.Errorf("Expected 3 exported spans, got %d", got)
+	}
+}
+
+func TestProviderStatsDroppedSpans(t *testing.T) {
+	exporter := &blockingExporter{release: make(chan struct{})}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.MaxQueueSize = 1
+	cfg.BatchSize = 1
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	// The exporter is stuck, so the tiny queue overflows
+	tracer := provider.TracerProvider.Tracer("test")
+	for i := 0; i < 50; i++ {
+		_, span := tracer.Start(context.Background(), "burst")
+		span.End()
+	}
+
+	close(exporter.release)
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	stats := provider.Stats()
+	if stats.Ended != 50 {
+		t.Errorf("Expected 50 ended spans, got %d", stats.Ended)
+	}
+	if stats.Exported == 0 {
+		t.Error("Expected some spans to be exported")
+	}
+	if stats.Dropped == 0 {
+		t.Error("Expected the overflowing queue to drop spans")
+	}
+	if stats.Ended != stats.Exported+stats.Failed+stats.Dropped {
+		t.Errorf("Expected every ended span to be accounted for, got %+v", stats)
+	}
+}
+
+func TestProviderBlockOnQueueFull(t *testing.T) {
+	exporter := &blockingExporter{release: make(chan struct{})}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.MaxQueueSize = 1
+	cfg.BatchSize = 1
+	cfg.BlockOnQueueFull = true
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	// Unblock the exporter after the queue has filled up
+	time.AfterFunc(50*time.Millisecond, func() {
+		close(exporter.release)
+	})
+
+	tracer := provider.TracerProvider.Tracer("test")
+	for i := 0; i < 20; i++ {
+		_, span := tracer.Start(context.Background(), "burst")
+		span.End()
+	}
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	// Backpressure instead of drops: every span is exported
+	if stats := provider.Stats(); stats.Exported != 20 || stats.Dropped != 0 {
+		t.Errorf("Expected all 20 spans to be exported without drops, got %+v", stats)
+	}
+}
+
+func TestSlowRequestThresholdEndDoesNotBlock(t *testing.T) {
+	exporter := &blockingExporter{release: make(chan struct{})}
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.Sampler = sdktrace.NeverSample()
+	cfg.SlowRequestThreshold = 100 * time.Millisecond
+	cfg.BatchTimeout = time.Millisecond
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer func() {
+		close(exporter.release)
+		provider.Shutdown(context.Background())
+	}()
+
+	tracer := provider.TracerProvider.Tracer("test")
+	start := time.Now()
+
+	// Slow spans must be queued, not exported on the goroutine ending them
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			_, slow := tracer.Start(context.Background(), "slow", trace.WithTimestamp(start))
+			slow.End(trace.WithTimestamp(start.Add(time.Second)))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected End to return while the exporter is blocked")
+	}
+}
@@ -0,0 +1,244 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCarrierRoundTrip(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+
+	// The helpers must not depend on the global propagator
+	cfg.DisableGlobal = true
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	// Producer side
+	ctx, span := provider.TracerProvider.Tracer("producer").Start(context.Background(), "publish")
+	defer span.End()
+
+	carrier := make(map[string]string)
+	provider.InjectToCarrier(ctx, carrier)
+
+	if carrier["traceparent"] == "" {
+		t.Fatal("Expected traceparent to be injected into the carrier")
+	}
+
+	// Consumer side
+	consumerCtx := provider.ContextFromCarrier(context.Background(), carrier)
+	remote := trace.SpanContextFromContext(consumerCtx)
+
+	if !remote.IsRemote() {
+		t.Error("Expected extracted span context to be remote")
+	}
+
+	if remote.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("Expected trace ID %s, got %s", span.SpanContext().TraceID(), remote.TraceID())
+	}
+
+	if remote.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected parent span ID %s, got %s", span.SpanContext().SpanID(), remote.SpanID())
+	}
+}
+
+func TestCarrierNil(t *testing.T) {
+	provider, err := vayuOtel.NewProvider(vayuOtel.Config{ServiceName: "test-service", Disabled: true})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	ctx := context.Background()
+	provider.InjectToCarrier(ctx, nil)
+
+	if got := provider.ContextFromCarrier(ctx, nil); got != ctx {
+		t.Error("Expected nil carrier to return the original context")
+	}
+}
+
+func TestDetachedContext(t *testing.T) {
+	provider, err := tests.SetupTestTracer()
+	if err != nil {
+		t.Fatalf("Failed to setup tracer: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	parent, cancel := context.WithCancel(context.Background())
+	parent = context.WithValue(parent, vayuOtel.GetTracerNameKey(), vayuOtel.GetDefaultTracerName())
+	parent, span := provider.Tracer("test").Start(parent, "request")
+	defer span.End()
+
+	detached := vayuOtel.DetachedContext(parent)
+	cancel()
+
+	if parent.Err() == nil {
+		t.Fatal("Expected parent context to be cancelled")
+	}
+
+	if detached.Err() != nil {
+		t.Errorf("Expected detached context not to be cancelled, got %v", detached.Err())
+	}
+
+	if !trace.SpanContextFromContext(detached).Equal(span.SpanContext()) {
+		t.Error("Expected detached context to carry the parent span context")
+	}
+
+	// Spans can still be started from the detached context
+	background := vayuOtel.Start(detached, "background-work")
+	defer background.End()
+
+	if background.Span.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Error("Expected background span to continue the request trace")
+	}
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	integration, err := tests.SetupTestIntegration(tests.NewInMemoryExporter())
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+	defer integration.Shutdown(context.Background())
+
+	var active, derived trace.SpanContext
+	var hasDeadline bool
+	tests.ServeMiddleware(integration.Middleware(), httptest.NewRequest(http.MethodGet, "/orders", nil), func(c *vayu.Context) {
+		ctx, cancel := vayuOtel.ContextWithTimeout(c, time.Second)
+		defer cancel()
+
+		active = vayuOtel.ActiveSpan(c).SpanContext()
+		derived = trace.SpanContextFromContext(ctx)
+		_, hasDeadline = ctx.Deadline()
+	})
+
+	if !active.IsValid() || !derived.Equal(active) {
+		t.Error("Expected the derived context to carry the active span")
+	}
+	if !hasDeadline {
+		t.Error("Expected the derived context to have a deadline")
+	}
+}
+
+func TestCloudTraceContextExtraction(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+		cfg.Propagators = []propagation.TextMapPropagator{
+			propagation.TraceContext{},
+			vayuOtel.CloudTraceContext{},
+		}
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/gcp", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	tests.ServeMiddleware(integration.Middleware(), req, nil)
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	if got := spans[0].SpanContext.TraceID().String(); got != "105445aa7843bc8bf206b12000100000" {
+		t.Errorf("Expected parent trace ID to be honored, got %s", got)
+	}
+
+	if got := spans[0].Parent.SpanID().String(); got != "0000000000000001" {
+		t.Errorf("Expected parent span ID 0000000000000001, got %s", got)
+	}
+}
+
+func TestCloudTraceContextRoundTrip(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02},
+		SpanID:     trace.SpanID{0x03},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	carrier := propagation.MapCarrier{}
+	vayuOtel.CloudTraceContext{}.Inject(ctx, carrier)
+
+	extracted := trace.SpanContextFromContext(vayuOtel.CloudTraceContext{}.Extract(context.Background(), carrier))
+	if extracted.TraceID() != sc.TraceID() || extracted.SpanID() != sc.SpanID() || !extracted.IsSampled() {
+		t.Errorf("Expected round-tripped span context %v, got %v", sc, extracted)
+	}
+}
+
+func TestMessageHeadersRoundTrip(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.TracerProvider.Tracer("producer").Start(context.Background(), "publish")
+	defer span.End()
+
+	// A fake Kafka-style message with its own header representation
+	headers := vayuOtel.MapCarrier{"content-type": "application/json"}
+	provider.InjectHeaders(ctx, headers.Set)
+
+	if headers["traceparent"] == "" {
+		t.Fatal("Expected traceparent to be written to the message headers")
+	}
+
+	consumerCtx := provider.ExtractHeaders(context.Background(), headers.Get, headers.Keys())
+	remote := trace.SpanContextFromContext(consumerCtx)
+
+	if remote.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("Expected trace ID %s, got %s", span.SpanContext().TraceID(), remote.TraceID())
+	}
+
+	if remote.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected parent span ID %s, got %s", span.SpanContext().SpanID(), remote.SpanID())
+	}
+}
+
+func TestMessageHeadersUseConfiguredPropagators(t *testing.T) {
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = tests.NewInMemoryExporter()
+	cfg.DisableGlobal = true
+	cfg.Propagators = []propagation.TextMapPropagator{vayuOtel.XRayPropagator{}}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.TracerProvider.Tracer("producer").Start(context.Background(), "publish")
+	defer span.End()
+
+	headers := vayuOtel.MapCarrier{}
+	provider.InjectHeaders(ctx, headers.Set)
+
+	if headers["x-amzn-trace-id"] == "" || headers["traceparent"] != "" {
+		t.Fatalf("Expected only the configured X-Ray header to be written, got %v", headers)
+	}
+
+	remote := trace.SpanContextFromContext(provider.ExtractHeaders(context.Background(), headers.Get, headers.Keys()))
+	if remote.TraceID() != span.SpanContext().TraceID() {
+		t.Errorf("Expected trace ID %s, got %s", span.SpanContext().TraceID(), remote.TraceID())
+	}
+}
@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedactAttributes(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.RedactAttributes = []string{"user.email", "auth.token"}
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	tracer := provider.TracerProvider.Tracer("test")
+	_, previous := tracer.Start(context.Background(), "previous")
+	previous.End()
+
+	_, span := tracer.Start(context.Background(), "login", trace.WithLinks(trace.Link{
+		SpanContext: previous.SpanContext(),
+		Attributes:  []attribute.KeyValue{attribute.String("auth.token", "link-token")},
+	}))
+	span.SetAttributes(
+		vayuOtel.StringAttribute("user.email", "jane@example.com"),
+		vayuOtel.StringAttribute("auth.token", "secret-token"),
+		vayuOtel.StringAttribute("user.plan", "pro"),
+	)
+	span.AddEvent("user.lookup", trace.WithAttributes(
+		attribute.String("user.email", "jane@example.com"),
+		attribute.String("user.plan", "pro"),
+	))
+	span.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	login := findSpan(t, exporter.GetSpans(), "login")
+	for _, key := range []string{"user.email", "auth.token"} {
+		if v, _ := tests.FindAttribute(login.Attributes, key); v.AsString() != "[REDACTED]" {
+			t.Errorf("Expected %s to be redacted, got %q", key, v.AsString())
+		}
+	}
+
+	if v, _ := tests.FindAttribute(login.Attributes, "user.plan"); v.AsString() != "pro" {
+		t.Errorf("Expected user.plan to be kept, got %q", v.AsString())
+	}
+
+	// Event and link attributes are redacted too
+	if len(login.Events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(login.Events))
+	}
+	if v, _ := tests.FindAttribute(login.Events[0].Attributes, "user.email"); v.AsString() != "[REDACTED]" {
+		t.Errorf("Expected the event's user.email to be redacted, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(login.Events[0].Attributes, "user.plan"); v.AsString() != "pro" {
+		t.Errorf("Expected the event's user.plan to be kept, got %q", v.AsString())
+	}
+	if len(login.Links) != 1 {
+		t.Fatalf("Expected 1 link, got %d", len(login.Links))
+	}
+	if v, _ := tests.FindAttribute(login.Links[0].Attributes, "auth.token"); v.AsString() != "[REDACTED]" {
+		t.Errorf("Expected the link's auth.token to be redacted, got %q", v.AsString())
+	}
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+)
+
+// hijackableRecorder is a response recorder that supports hijacking
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestTracingResponseWriterStatus(t *testing.T) {
+	w := vayuOtel.NewTracingResponseWriter(httptest.NewRecorder())
+
+	if w.WroteHeader() {
+		t.Error("Expected WroteHeader to be false before writing")
+	}
+
+	if w.StatusCode() != http.StatusOK {
+		t.Errorf("Expected default status 200, got %d", w.StatusCode())
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.WriteHeader(http.StatusInternalServerError)
+
+	if !w.WroteHeader() {
+		t.Error("Expected WroteHeader to be true after WriteHeader")
+	}
+
+	if w.StatusCode() != http.StatusNotFound {
+		t.Errorf("Expected first status 404 to be kept, got %d", w.StatusCode())
+	}
+}
+
+func TestTracingResponseWriterBytesWritten(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := vayuOtel.NewTracingResponseWriter(recorder)
+
+	w.Write([]byte("hello "))
+	w.Write([]byte("world"))
+
+	if w.BytesWritten() != 11 {
+		t.Errorf("Expected 11 bytes written, got %d", w.BytesWritten())
+	}
+
+	if w.StatusCode() != http.StatusOK {
+		t.Errorf("Expected implicit status 200, got %d", w.StatusCode())
+	}
+
+	if recorder.Body.String() != "hello world" {
+		t.Errorf("Expected body to reach the wrapped writer, got %q", recorder.Body.String())
+	}
+}
+
+func TestTracingResponseWriterHijack(t *testing.T) {
+	underlying := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w := vayuOtel.NewTracingResponseWriter(underlying)
+
+	if _, _, err := w.Hijack(); err != nil {
+		t.Fatalf("Expected hijack to pass through, got %v", err)
+	}
+
+	if !underlying.hijacked {
+		t.Error("Expected the wrapped writer to be hijacked")
+	}
+
+	if w.StatusCode() != http.StatusSwitchingProtocols {
+		t.Errorf("Expected status 101 after hijack, got %d", w.StatusCode())
+	}
+
+	// Writers that cannot be hijacked report an error
+	if _, _, err := vayuOtel.NewTracingResponseWriter(httptest.NewRecorder()).Hijack(); err == nil {
+		t.Error("Expected an error hijacking a writer without Hijacker support")
+	}
+}
@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+)
+
+func TestTraceHandler(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	// Registration must not panic
+	integration.TracedGET(vayu.New(), "/users/:id", func(c *vayu.Context, next vayu.NextFunc) {})
+
+	handler := integration.TraceHandler("/users/:id", func(c *vayu.Context, next vayu.NextFunc) {
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	tests.ServeMiddleware(integration.Middleware(), req, func(c *vayu.Context) {
+		handler(c, func() {})
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected server and handler spans, got %d", len(spans))
+	}
+
+	server := findSpan(t, spans, "HTTP GET /users/42")
+	handlerSpan := findSpan(t, spans, "GET /users/:id")
+
+	if handlerSpan.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("Expected handler span to be a child of the server span")
+	}
+
+	if v, _ := tests.FindAttribute(handlerSpan.Attributes, "http.route"); v.AsString() != "/users/:id" {
+		t.Errorf("Expected http.route=/users/:id, got %q", v.AsString())
+	}
+}
+
+func TestMiddlewareCollapseUnmatchedRoutes(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.CollapseUnmatchedRoutes = true
+	middleware := integration.Middleware(opts)
+
+	notFound := func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusNotFound)
+	}
+
+	// No route matched: the router answers 404 itself
+	tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/wp-admin/login.php", nil), notFound)
+
+	// A traced route that returns 404 keeps its name
+	handler := integration.TraceHandler("/reports", func(c *vayu.Context, next vayu.NextFunc) {
+		notFound(c)
+	})
+	tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/reports", nil), func(c *vayu.Context) {
+		handler(c, func() {})
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	findSpan(t, spans, "HTTP GET [unmatched]")
+	findSpan(t, spans, "HTTP GET /reports")
+}
+
+// routeGroup collects the middleware registered on it
+type routeGroup struct {
+	handlers []vayu.HandlerFunc
+}
+
+func (g *routeGroup) Use(handler vayu.HandlerFunc) {
+	g.handlers = append(g.handlers, handler)
+}
+
+func TestTraceGroup(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	v1, v2 := &routeGroup{}, &routeGroup{}
+	integration.TraceGroup(v1, map[string]interface{}{"api.version": "v1"})
+	integration.TraceGroup(v2, map[string]interface{}{"api.version": "v2", "api.beta": true})
+
+	for path, group := range map[string]*routeGroup{"/v1/users": v1, "/v2/users": v2} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		tests.ServeMiddleware(integration.Middleware(), req, func(c *vayu.Context) {
+			group.handlers[0](c, func() {})
+		})
+	}
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	v1Span := findSpan(t, spans, "HTTP GET /v1/users")
+	v2Span := findSpan(t, spans, "HTTP GET /v2/users")
+
+	if v, _ := tests.FindAttribute(v1Span.Attributes, "api.version"); v.AsString() != "v1" {
+		t.Errorf("Expected api.version=v1 on v1 routes, got %q", v.AsString())
+	}
+	if _, ok := tests.FindAttribute(v1Span.Attributes, "api.beta"); ok {
+		t.Error("Expected v2 group attributes not to appear on v1 routes")
+	}
+	if v, _ := tests.FindAttribute(v2Span.Attributes, "api.version"); v.AsString() != "v2" {
+		t.Errorf("Expected api.version=v2 on v2 routes, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(v2Span.Attributes, "api.beta"); !v.AsBool() {
+		t.Error("Expected api.beta=true on v2 routes")
+	}
+}
+
+func TestMiddlewareHTTPRouteFromTraceHandler(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	// Mounted under a prefix, so the path does not match the registered template itself
+	handler := integration.TraceHandler("/users/:id", func(c *vayu.Context, next vayu.NextFunc) {})
+	tests.ServeMiddleware(integration.Middleware(), httptest.NewRequest(http.MethodGet, "/api/users/42", nil), func(c *vayu.Context) {
+		handler(c, func() {})
+	})
+
+	// Requests no route handled carry no http.route rather than their raw path
+	tests.ServeMiddleware(integration.Middleware(), httptest.NewRequest(http.MethodGet, "/wp-admin/x.php", nil), func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusNotFound)
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if v, _ := tests.FindAttribute(findSpan(t, spans, "HTTP GET /api/users/42").Attributes, "http.route"); v.AsString() != "/users/:id" {
+		t.Errorf("Expected http.route=/users/:id from TraceHandler, got %q", v.AsString())
+	}
+	if v, ok := tests.FindAttribute(findSpan(t, spans, "HTTP GET /wp-admin/x.php").Attributes, "http.route"); ok {
+		t.Errorf("Expected no http.route for an unmatched request, got %q", v.AsString())
+	}
+}
+
+func TestAddServerSpanAttributesInTraceHandler(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter)
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	// TraceHandler replaces the current span with its child span
+	var active string
+	handler := integration.TraceHandler("/profile", func(c *vayu.Context, next vayu.NextFunc) {
+		vayuOtel.AddServerSpanAttributes(c, map[string]interface{}{"user.id": "42"})
+		active = vayuOtel.GetSpanID(c)
+	})
+	tests.ServeMiddleware(integration.Middleware(), httptest.NewRequest(http.MethodGet, "/profile", nil), func(c *vayu.Context) {
+		handler(c, func() {})
+	})
+
+	if err := integration.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	server := findSpan(t, spans, "HTTP GET /profile")
+	if v, _ := tests.FindAttribute(server.Attributes, "user.id"); v.AsString() != "42" {
+		t.Errorf("Expected user.id=42 on the server span, got %q", v.AsString())
+	}
+	if _, ok := tests.FindAttribute(findSpan(t, spans, "GET /profile").Attributes, "user.id"); ok {
+		t.Error("Expected the handler span not to receive server span attributes")
+	}
+	if active != server.SpanContext.SpanID().String() {
+		t.Errorf("Expected ActiveSpan to be the server span %s, got %s", server.SpanContext.SpanID(), active)
+	}
+}
@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestRouteSampler(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+		cfg.Sampler = vayuOtel.NewRouteSampler(sdktrace.AlwaysSample(), map[string]sdktrace.Sampler{
+			"/health":    sdktrace.NeverSample(),
+			"/users/:id": sdktrace.NeverSample(),
+		})
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	integration.RegisterRoutes("/health", "/users/:id")
+
+	middleware := integration.Middleware()
+	for i := 0; i < 5; i++ {
+		tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/health", nil), nil)
+		tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/checkout", nil), nil)
+	}
+
+	// Parameterized routes are matched by their template
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	recorder := httptest.NewRecorder()
+	c := &vayu.Context{Request: req, Writer: recorder, Params: map[string]string{"id": "42"}}
+	middleware(c, func() {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := integration.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 5 {
+		t.Fatalf("Expected 5 sampled spans, got %d", len(spans))
+	}
+
+	for _, span := range spans {
+		if span.Name != "HTTP GET /checkout" {
+			t.Errorf("Expected only /checkout spans to be sampled, got %q", span.Name)
+		}
+	}
+}
+
+func TestRouteSamplerDescription(t *testing.T) {
+	sampler := vayuOtel.NewRouteSampler(nil, nil)
+	if sampler.Description() == "" {
+		t.Error("Expected non-empty sampler description")
+	}
+}
+
+func TestSampleRatioRespectsParent(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ratio       float64
+		traceparent string
+		want        int
+	}{
+		// A sampled parent forces sampling even though almost no roots are sampled
+		{"sampled parent", 1e-9, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", 1},
+		// An unsampled parent drops the span even though every root is sampled
+		{"unsampled parent", 1, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", 0},
+		{"root", 1, "", 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter := tests.NewInMemoryExporter()
+			integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+				cfg.SampleRatio = tc.ratio
+			})
+			if err != nil {
+				t.Fatalf("Failed to set up integration: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			tests.ServeMiddleware(integration.Middleware(), req, nil)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := integration.Shutdown(ctx); err != nil {
+				t.Fatalf("Failed to shut down integration: %v", err)
+			}
+
+			if got := len(exporter.GetSpans()); got != tc.want {
+				t.Errorf("Expected %d sampled spans, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestForceSampleOnDebugHeader(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+		cfg.Sampler = sdktrace.NeverSample()
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.ForceSampleOnDebugHeader = true
+	middleware := integration.Middleware(opts)
+
+	debug := httptest.NewRequest(http.MethodGet, "/debug", nil)
+	debug.Header.Set(vayuOtel.DebugTraceHeader, "1")
+	tests.ServeMiddleware(middleware, debug, nil)
+	tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/normal", nil), nil)
+
+	// The header is ignored unless the option is enabled
+	ignored := httptest.NewRequest(http.MethodGet, "/ignored", nil)
+	ignored.Header.Set(vayuOtel.DebugTraceHeader, "1")
+	tests.ServeMiddleware(integration.Middleware(), ignored, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := integration.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "HTTP GET /debug" {
+		t.Errorf("Expected only the debug request to be sampled, got %d spans", len(spans))
+	}
+}
+
+func TestPrioritySampler(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+	integration, err := tests.SetupTestIntegration(exporter, func(cfg *vayuOtel.Config) {
+		cfg.Sampler = vayuOtel.NewPrioritySampler(sdktrace.NeverSample(), 10)
+	})
+	if err != nil {
+		t.Fatalf("Failed to set up integration: %v", err)
+	}
+
+	opts := vayuOtel.DefaultMiddlewareOptions()
+	opts.RoutePriority = func(c *vayu.Context) int {
+		if c.Request.URL.Path == "/checkout" {
+			return 10
+		}
+		return 0
+	}
+	middleware := integration.Middleware(opts)
+
+	for i := 0; i < 5; i++ {
+		tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/checkout", nil), nil)
+		tests.ServeMiddleware(middleware, httptest.NewRequest(http.MethodGet, "/browse", nil), nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := integration.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shut down integration: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 5 {
+		t.Fatalf("Expected 5 sampled spans, got %d", len(spans))
+	}
+	for _, span := range spans {
+		if span.Name != "HTTP GET /checkout" {
+			t.Errorf("Expected only high-priority requests to be sampled, got %q", span.Name)
+		}
+	}
+}
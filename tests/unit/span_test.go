@@ -0,0 +1,514 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupSpanTest creates a provider exporting to an in-memory exporter and a root span context
+// for vayuOtel.Start; the returned function ends the root span and returns the exported spans
+func setupSpanTest(t *testing.T) (context.Context, func() tracetest.SpanStubs) {
+	t.Helper()
+
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), vayuOtel.GetTracerNameKey(), vayuOtel.GetDefaultTracerName())
+	ctx, root := provider.TracerProvider.Tracer("test").Start(ctx, "root")
+
+	return ctx, func() tracetest.SpanStubs {
+		root.End()
+		if err := provider.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Failed to shut down provider: %v", err)
+		}
+		return exporter.GetSpans()
+	}
+}
+
+// findSpan returns the exported span with the given name
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("Expected span %q to be exported", name)
+	return tracetest.SpanStub{}
+}
+
+func TestStartWithLinks(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	producer1 := vayuOtel.Start(ctx, "producer-1")
+	producer1.End()
+	producer2 := vayuOtel.Start(ctx, "producer-2")
+	producer2.End()
+
+	consumer := vayuOtel.Start(ctx, "consumer",
+		vayuOtel.WithLink(producer1.Span.SpanContext(), map[string]interface{}{"message.id": "m-1"}),
+		vayuOtel.WithLink(producer2.Span.SpanContext(), map[string]interface{}{"message.id": "m-2"}),
+		vayuOtel.WithStringAttribute("consumer.group", "workers"),
+	)
+	consumer.End()
+
+	span := findSpan(t, collect(), "consumer")
+	if len(span.Links) != 2 {
+		t.Fatalf("Expected 2 links, got %d", len(span.Links))
+	}
+
+	if !span.Links[0].SpanContext.Equal(producer1.Span.SpanContext()) {
+		t.Error("Expected first link to point at producer-1")
+	}
+
+	if v, _ := tests.FindAttribute(span.Links[1].Attributes, "message.id"); v.AsString() != "m-2" {
+		t.Errorf("Expected second link message.id=m-2, got %q", v.AsString())
+	}
+
+	if _, ok := tests.FindAttribute(span.Attributes, "consumer.group"); !ok {
+		t.Error("Expected regular span options to still apply alongside links")
+	}
+}
+
+func TestSpanIsRecording(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	recording := vayuOtel.Start(ctx, "recording")
+	if !recording.IsRecording() {
+		t.Error("Expected span from an SDK provider to be recording")
+	}
+
+	if !recording.SpanContext().IsValid() {
+		t.Error("Expected recording span to have a valid span context")
+	}
+	recording.End()
+	collect()
+
+	// Without an active span the no-op provider is used
+	noop := vayuOtel.ActiveSpan(nil)
+	if noop.IsRecording() {
+		t.Error("Expected no-op span not to be recording")
+	}
+
+	if noop.SpanContext().IsValid() {
+		t.Error("Expected no-op span to have an invalid span context")
+	}
+}
+
+func TestWithSpan(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	errFailed := errors.New("work failed")
+	err := vayuOtel.WithSpan(ctx, "failing", func(ctx context.Context, span *vayuOtel.Span) error {
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("Expected WithSpan to return the function's error, got %v", err)
+	}
+
+	var childCtx context.Context
+	err = vayuOtel.WithSpan(ctx, "succeeding", func(ctx context.Context, span *vayuOtel.Span) error {
+		childCtx = ctx
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if trace.SpanFromContext(childCtx).IsRecording() {
+		t.Error("Expected span to be ended after WithSpan returns")
+	}
+
+	spans := collect()
+
+	failing := findSpan(t, spans, "failing")
+	if failing.Status.Code != codes.Error {
+		t.Errorf("Expected failing span to have error status, got %v", failing.Status.Code)
+	}
+
+	if len(failing.Events) != 1 || failing.Events[0].Name != "exception" {
+		t.Error("Expected failing span to record the error as an exception event")
+	}
+
+	succeeding := findSpan(t, spans, "succeeding")
+	if succeeding.Status.Code == codes.Error {
+		t.Error("Expected succeeding span not to have error status")
+	}
+}
+
+func TestSpanEndWithOptions(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	start := time.Now().Add(-time.Minute)
+	end := start.Add(10 * time.Second)
+
+	past := vayuOtel.Start(ctx, "past-work")
+	past.EndWithOptions(end)
+
+	variadic := vayuOtel.Start(ctx, "variadic-end")
+	variadic.End(trace.WithTimestamp(end))
+
+	spans := collect()
+
+	for _, name := range []string{"past-work", "variadic-end"} {
+		span := findSpan(t, spans, name)
+		if !span.EndTime.Equal(end) {
+			t.Errorf("Expected %s to end at %v, got %v", name, end, span.EndTime)
+		}
+	}
+}
+
+func TestSpanAddEventAt(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	happened := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	span := vayuOtel.Start(ctx, "replay")
+	span.AddEventAt("order.placed", happened, map[string]interface{}{"order.id": "42"})
+	span.End()
+
+	replay := findSpan(t, collect(), "replay")
+	if len(replay.Events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(replay.Events))
+	}
+
+	event := replay.Events[0]
+	if !event.Time.Equal(happened) {
+		t.Errorf("Expected event time %v, got %v", happened, event.Time)
+	}
+
+	if v, _ := tests.FindAttribute(event.Attributes, "order.id"); v.AsString() != "42" {
+		t.Errorf("Expected order.id=42, got %q", v.AsString())
+	}
+}
+
+func TestNoopSpan(t *testing.T) {
+	span := vayuOtel.NoopSpan()
+	if span == nil {
+		t.Fatal("Expected NoopSpan to return a non-nil span")
+	}
+
+	// All fluent methods must be safe no-ops
+	span.AddAttributes(map[string]interface{}{"key": "value"}).
+		AddEvent("event", map[string]interface{}{"key": "value"}).
+		RecordError(errors.New("boom")).
+		End()
+
+	if span.IsRecording() {
+		t.Error("Expected the no-op span not to record")
+	}
+	if span.SpanContext().IsValid() {
+		t.Error("Expected the no-op span to have an invalid span context")
+	}
+	if span.Context() == nil {
+		t.Error("Expected the no-op span to have a context")
+	}
+}
+
+func TestStartWithTraceState(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	state, err := trace.ParseTraceState("vendor=hint")
+	if err != nil {
+		t.Fatalf("Failed to parse trace state: %v", err)
+	}
+
+	span := vayuOtel.Start(ctx, "with-state", vayuOtel.WithTraceState(state))
+	if got := span.TraceState().Get("vendor"); got != "hint" {
+		t.Errorf("Expected trace state vendor=hint, got %q", got)
+	}
+
+	// Children inherit the trace state
+	child := vayuOtel.Start(span.Context(), "child")
+	child.End()
+	span.End()
+
+	spans := collect()
+	if got := findSpan(t, spans, "child").SpanContext.TraceState().Get("vendor"); got != "hint" {
+		t.Errorf("Expected child to inherit vendor=hint, got %q", got)
+	}
+	if findSpan(t, spans, "with-state").Parent.SpanID() != findSpan(t, spans, "root").SpanContext.SpanID() {
+		t.Error("Expected the span to keep its parent")
+	}
+}
+
+// notFoundError is a custom error type used to check error.type
+type notFoundError struct {
+	id string
+}
+
+func (e *notFoundError) Error() string {
+	return "not found: " + e.id
+}
+
+func TestRecordErrorType(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	vayuOtel.Start(ctx, "typed").RecordError(&notFoundError{id: "42"}).End()
+	vayuOtel.Start(ctx, "coded").RecordErrorWithCode(errors.New("card declined"), "PAYMENT_DECLINED").End()
+	vayuOtel.Start(ctx, "nil").RecordError(nil).End()
+
+	spans := collect()
+
+	typed := findSpan(t, spans, "typed")
+	if v, _ := tests.FindAttribute(typed.Attributes, "error.type"); v.AsString() != "*unit.notFoundError" {
+		t.Errorf("Expected error.type=*unit.notFoundError, got %q", v.AsString())
+	}
+
+	coded := findSpan(t, spans, "coded")
+	if v, _ := tests.FindAttribute(coded.Attributes, "error.code"); v.AsString() != "PAYMENT_DECLINED" {
+		t.Errorf("Expected error.code=PAYMENT_DECLINED, got %q", v.AsString())
+	}
+	if coded.Status.Code != codes.Error {
+		t.Errorf("Expected status Error, got %v", coded.Status.Code)
+	}
+
+	if span := findSpan(t, spans, "nil"); span.Status.Code != codes.Unset || len(span.Events) != 0 {
+		t.Error("Expected a nil error to be ignored")
+	}
+}
+
+func TestSpanAddEvents(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	shipped := time.Now().Add(-time.Minute)
+	vayuOtel.Start(ctx, "order").AddEvents([]vayuOtel.SpanEvent{
+		{Name: "created"},
+		{Name: "paid", Attributes: map[string]interface{}{"amount": 42.5}},
+		{Name: "shipped", Time: shipped},
+	}).End()
+
+	events := findSpan(t, collect(), "order").Events
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	for i, name := range []string{"created", "paid", "shipped"} {
+		if events[i].Name != name {
+			t.Errorf("Expected event %d to be %q, got %q", i, name, events[i].Name)
+		}
+	}
+	if v, _ := tests.FindAttribute(events[1].Attributes, "amount"); v.AsFloat64() != 42.5 {
+		t.Errorf("Expected amount=42.5, got %v", v.AsFloat64())
+	}
+	if !events[2].Time.Equal(shipped) {
+		t.Errorf("Expected shipped event at %v, got %v", shipped, events[2].Time)
+	}
+	if events[0].Time.IsZero() {
+		t.Error("Expected events without a time to be timestamped")
+	}
+}
+
+func TestStartSibling(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	parent := vayuOtel.Start(ctx, "parent")
+	first := vayuOtel.Start(parent.Context(), "first")
+	sibling := vayuOtel.StartSibling(first, "sibling", vayuOtel.WithStringAttribute("span.type", "sibling"))
+	sibling.End()
+	first.End()
+	parent.End()
+
+	spans := collect()
+	parentID := findSpan(t, spans, "parent").SpanContext.SpanID()
+
+	if got := findSpan(t, spans, "first").Parent.SpanID(); got != parentID {
+		t.Errorf("Expected first span to be a child of parent, got parent %s", got)
+	}
+	if got := findSpan(t, spans, "sibling").Parent.SpanID(); got != parentID {
+		t.Errorf("Expected sibling to share the parent of first, got parent %s", got)
+	}
+	if v, _ := tests.FindAttribute(findSpan(t, spans, "sibling").Attributes, "span.type"); v.AsString() != "sibling" {
+		t.Error("Expected sibling span options to be applied")
+	}
+}
+
+func TestStartContext(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	spanCtx, span := vayuOtel.StartContext(ctx, "outer")
+	if spanCtx != span.Context() {
+		t.Error("Expected the returned context to be the span's context")
+	}
+
+	vayuOtel.Start(spanCtx, "inner").End()
+	span.End()
+
+	spans := collect()
+	if findSpan(t, spans, "inner").Parent.SpanID() != findSpan(t, spans, "outer").SpanContext.SpanID() {
+		t.Error("Expected spans started from the returned context to be children of the span")
+	}
+}
+
+func TestStartEntryPoints(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	fluent := vayuOtel.Start(ctx, "fluent", vayuOtel.WithStringAttribute("entry", "fluent"))
+	rawCtx, raw := vayuOtel.StartRaw(fluent.Context(), "raw", vayuOtel.WithStringAttribute("entry", "raw"))
+	if trace.SpanFromContext(rawCtx) != raw {
+		t.Error("Expected the raw context to carry the raw span")
+	}
+	raw.End()
+	fluent.End()
+
+	spans := collect()
+	rawSpan := findSpan(t, spans, "raw")
+	if rawSpan.Parent.SpanID() != findSpan(t, spans, "fluent").SpanContext.SpanID() {
+		t.Error("Expected the raw span to be a child of the fluent span")
+	}
+	if v, _ := tests.FindAttribute(rawSpan.Attributes, "entry"); v.AsString() != "raw" {
+		t.Error("Expected span options to apply to StartRaw")
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+	defer collect()
+
+	span := vayuOtel.Start(ctx, "failing")
+	defer span.End()
+
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("loading user: %w", vayuOtel.WrapError(span.Context(), cause))
+
+	var traced *vayuOtel.TracedError
+	if !errors.As(err, &traced) {
+		t.Fatal("Expected the wrapped error to be a TracedError")
+	}
+	if traced.TraceID() != span.SpanContext().TraceID() || traced.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected IDs %s/%s, got %s/%s", span.SpanContext().TraceID(), span.SpanContext().SpanID(), traced.TraceID(), traced.SpanID())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the original error to be reachable with errors.Is")
+	}
+	if traced.Error() != cause.Error() {
+		t.Errorf("Expected message %q, got %q", cause.Error(), traced.Error())
+	}
+
+	if vayuOtel.WrapError(ctx, nil) != nil {
+		t.Error("Expected wrapping a nil error to return nil")
+	}
+}
+
+func TestStartWithNewRoot(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	parent := vayuOtel.Start(ctx, "request")
+	job := vayuOtel.Start(parent.Context(), "cron-job", vayuOtel.WithNewRoot(), vayuOtel.WithStringAttribute("job", "cleanup"))
+	job.End()
+	parent.End()
+
+	spans := collect()
+	jobSpan := findSpan(t, spans, "cron-job")
+	if jobSpan.SpanContext.TraceID() == findSpan(t, spans, "request").SpanContext.TraceID() {
+		t.Error("Expected the new root span to start a new trace")
+	}
+	if jobSpan.Parent.IsValid() {
+		t.Error("Expected the new root span to have no parent")
+	}
+	if v, _ := tests.FindAttribute(jobSpan.Attributes, "job"); v.AsString() != "cleanup" {
+		t.Error("Expected other options to apply alongside WithNewRoot")
+	}
+}
+
+func TestSuppressTracing(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	suppressed := vayuOtel.SuppressTracing(ctx)
+	for i := 0; i < 3; i++ {
+		span := vayuOtel.Start(suppressed, "loop")
+		if span.IsRecording() {
+			t.Error("Expected a no-op span under suppression")
+		}
+		// Descendants of suppressed spans are suppressed too
+		vayuOtel.Start(span.Context(), "nested").End()
+		span.End()
+	}
+
+	vayuOtel.Start(ctx, "after").End()
+
+	spans := collect()
+	for _, span := range spans {
+		if span.Name == "loop" || span.Name == "nested" {
+			t.Errorf("Expected span %q created under suppression not to be exported", span.Name)
+		}
+	}
+	findSpan(t, spans, "after")
+}
+
+func TestSpanAddStruct(t *testing.T) {
+	ctx, collect := setupSpanTest(t)
+
+	type tier string
+	type order struct {
+		ID       string  `otel:"id"`
+		Items    int     `otel:"item_count"`
+		Total    float64 `otel:"total"`
+		Paid     bool
+		Tier     tier
+		Secret   string `otel:"-"`
+		Tags     []string
+		internal string
+		Weight   uint    `otel:"weight"`
+		Sequence uint64  `otel:"sequence"`
+		Coupon   *string `otel:"coupon"`
+		Note     *string `otel:"note"`
+	}
+
+	coupon := "SAVE10"
+	span := vayuOtel.Start(ctx, "checkout")
+	span.AddStruct("order", &order{ID: "o-1", Items: 3, Total: 42.5, Paid: true, Tier: "gold", Secret: "s", Tags: []string{"a"}, internal: "x",
+		Weight: 7, Sequence: math.MaxUint64, Coupon: &coupon})
+	span.AddStruct("order", "not a struct")
+	span.End()
+
+	attrs := findSpan(t, collect(), "checkout").Attributes
+
+	if v, _ := tests.FindAttribute(attrs, "order.id"); v.AsString() != "o-1" {
+		t.Errorf("Expected order.id=o-1, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.item_count"); v.AsInt64() != 3 {
+		t.Errorf("Expected order.item_count=3, got %d", v.AsInt64())
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.total"); v.AsFloat64() != 42.5 {
+		t.Errorf("Expected order.total=42.5, got %v", v.AsFloat64())
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.Paid"); !v.AsBool() {
+		t.Error("Expected untagged field to use its name, order.Paid=true")
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.Tier"); v.AsString() != "gold" {
+		t.Errorf("Expected named string type to be recorded, got %q", v.AsString())
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.weight"); v.AsInt64() != 7 {
+		t.Errorf("Expected order.weight=7, got %d", v.AsInt64())
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.sequence"); v.AsString() != "18446744073709551615" {
+		t.Errorf("Expected a uint64 beyond int64 to be recorded as a string, got %q", v.Emit())
+	}
+	if v, _ := tests.FindAttribute(attrs, "order.coupon"); v.AsString() != "SAVE10" {
+		t.Errorf("Expected the pointer field to be dereferenced, got %q", v.AsString())
+	}
+	for _, key := range []string{"order.Secret", "order.-", "order.Tags", "order.internal", "order.note"} {
+		if _, ok := tests.FindAttribute(attrs, key); ok {
+			t.Errorf("Expected %s to be skipped", key)
+		}
+	}
+}
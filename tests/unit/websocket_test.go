@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kaushiksamanta/vayu"
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareWebSocketUpgrade(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {
+		conn := vayuOtel.StartWebSocketSpan(c.Request.Context())
+		c.Writer.WriteHeader(http.StatusSwitchingProtocols)
+
+		// Simulate the connection closing
+		conn.End()
+	})
+
+	if len(spans) != 2 {
+		t.Fatalf("Expected handshake and connection spans, got %d", len(spans))
+	}
+
+	handshake := findSpan(t, spans, "HTTP GET /ws")
+	if handshake.SpanKind != trace.SpanKindServer {
+		t.Errorf("Expected handshake span kind server, got %v", handshake.SpanKind)
+	}
+
+	if v, _ := tests.FindAttribute(handshake.Attributes, "http.upgrade"); v.AsString() != "websocket" {
+		t.Errorf("Expected http.upgrade=websocket, got %q", v.AsString())
+	}
+
+	if v, _ := tests.FindAttribute(handshake.Attributes, "http.status_code"); v.AsInt64() != http.StatusSwitchingProtocols {
+		t.Errorf("Expected http.status_code=101, got %d", v.AsInt64())
+	}
+
+	if handshake.Status.Code == codes.Error {
+		t.Error("Expected handshake span not to be marked as error")
+	}
+
+	conn := findSpan(t, spans, "WebSocket connection")
+	if conn.Parent.SpanID() != handshake.SpanContext.SpanID() {
+		t.Error("Expected connection span to be a child of the handshake span")
+	}
+}
+
+func TestMiddlewareWebSocketHandshakeEndsOnSwitch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+
+	var recordingAfterSwitch bool
+	spans := serveAndCollect(t, vayuOtel.DefaultMiddlewareOptions(), req, func(c *vayu.Context) {
+		c.Writer.WriteHeader(http.StatusSwitchingProtocols)
+		recordingAfterSwitch = vayuOtel.ActiveSpan(c).IsRecording()
+
+		// The connection is served after the handshake span has ended
+		conn := vayuOtel.StartWebSocketSpan(c.Request.Context())
+		conn.End()
+	})
+
+	if recordingAfterSwitch {
+		t.Error("Expected the handshake span to end when the 101 is written")
+	}
+
+	handshake := findSpan(t, spans, "HTTP GET /ws")
+	if v, _ := tests.FindAttribute(handshake.Attributes, "http.status_code"); v.AsInt64() != http.StatusSwitchingProtocols {
+		t.Errorf("Expected http.status_code=101, got %d", v.AsInt64())
+	}
+	if conn := findSpan(t, spans, "WebSocket connection"); conn.Parent.SpanID() != handshake.SpanContext.SpanID() {
+		t.Error("Expected connection span to be a child of the handshake span")
+	}
+}
@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	vayuOtel "github.com/kaushiksamanta/vayu-otel"
+	"github.com/kaushiksamanta/vayu-otel/tests"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestXRayIDGenerator(t *testing.T) {
+	exporter := tests.NewInMemoryExporter()
+
+	cfg := vayuOtel.DefaultConfig()
+	cfg.Exporter = exporter
+	cfg.IDGenerator = vayuOtel.NewXRayIDGenerator()
+
+	provider, err := vayuOtel.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	before := time.Now().Unix()
+	_, span := provider.TracerProvider.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	after := time.Now().Unix()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shut down provider: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	// X-Ray trace IDs start with the Unix time in seconds
+	traceID := spans[0].SpanContext.TraceID()
+	epoch := int64(binary.BigEndian.Uint32(traceID[:4]))
+	if epoch < before || epoch > after {
+		t.Errorf("Expected trace ID to start with a timestamp between %d and %d, got %d", before, after, epoch)
+	}
+	if !spans[0].SpanContext.SpanID().IsValid() {
+		t.Error("Expected a valid span ID")
+	}
+}
+
+func TestXRayPropagatorRoundTrip(t *testing.T) {
+	carrier := propagation.MapCarrier{
+		"x-amzn-trace-id": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+	}
+
+	ctx := vayuOtel.XRayPropagator{}.Extract(context.Background(), carrier)
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.TraceID().String() != "5759e988bd862e3fe1be46a994272793" || sc.SpanID().String() != "53995c3f42cd8ad8" || !sc.IsSampled() {
+		t.Fatalf("Expected the X-Ray header to be extracted, got %v", sc)
+	}
+
+	injected := propagation.MapCarrier{}
+	vayuOtel.XRayPropagator{}.Inject(ctx, injected)
+	if got := injected.Get("x-amzn-trace-id"); got != carrier.Get("x-amzn-trace-id") {
+		t.Errorf("Expected round-tripped header %q, got %q", carrier.Get("x-amzn-trace-id"), got)
+	}
+}
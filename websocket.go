@@ -0,0 +1,16 @@
+package vayuotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartWebSocketSpan starts a long-lived span representing a WebSocket connection, as a child
+// of the handshake span in ctx. The handler must End it when the connection closes
+func StartWebSocketSpan(ctx context.Context, opts ...SpanOption) *Span {
+	return startSpan(ctx, "WebSocket connection", []trace.SpanStartOption{
+		trace.WithAttributes(attribute.String("network.protocol.name", "websocket")),
+	}, opts...)
+}
@@ -0,0 +1,138 @@
+package vayuotel
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// xrayIDGenerator generates AWS X-Ray compatible IDs: trace IDs start with the
+// 4-byte big-endian Unix time in seconds, followed by 12 random bytes
+type xrayIDGenerator struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+var _ sdktrace.IDGenerator = (*xrayIDGenerator)(nil)
+
+// NewXRayIDGenerator returns an ID generator for Config.IDGenerator whose trace IDs are
+// accepted by AWS X-Ray
+func NewXRayIDGenerator() sdktrace.IDGenerator {
+	return &xrayIDGenerator{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// NewIDs implements sdktrace.IDGenerator
+func (g *xrayIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var traceID trace.TraceID
+	binary.BigEndian.PutUint32(traceID[:4], uint32(time.Now().Unix()))
+	_, _ = g.rand.Read(traceID[4:])
+
+	return traceID, g.newSpanID()
+}
+
+// NewSpanID implements sdktrace.IDGenerator
+func (g *xrayIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.newSpanID()
+}
+
+// newSpanID returns a random non-zero span ID; callers must hold g.mu
+func (g *xrayIDGenerator) newSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	for !spanID.IsValid() {
+		_, _ = g.rand.Read(spanID[:])
+	}
+	return spanID
+}
+
+// xrayTraceHeader is the AWS X-Ray trace header, formatted as
+// "Root=1-TTTTTTTT-RRRRRRRRRRRRRRRRRRRRRRRR;Parent=SPAN_ID;Sampled=1"
+const xrayTraceHeader = "x-amzn-trace-id"
+
+// XRayPropagator propagates trace context in the AWS X-Amzn-Trace-Id header, so traces
+// join the X-Ray console. Use it with NewXRayIDGenerator. On extraction it acts as a
+// fallback: a span context already extracted by an earlier propagator is kept
+type XRayPropagator struct{}
+
+var _ propagation.TextMapPropagator = XRayPropagator{}
+
+// Inject implements propagation.TextMapPropagator
+func (XRayPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	traceID := sc.TraceID().String()
+	sampled := 0
+	if sc.IsSampled() {
+		sampled = 1
+	}
+	carrier.Set(xrayTraceHeader, fmt.Sprintf("Root=1-%s-%s;Parent=%s;Sampled=%d", traceID[:8], traceID[8:], sc.SpanID(), sampled))
+}
+
+// Extract implements propagation.TextMapPropagator
+func (XRayPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	sc, ok := parseXRayTraceHeader(carrier.Get(xrayTraceHeader))
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields implements propagation.TextMapPropagator
+func (XRayPropagator) Fields() []string {
+	return []string{xrayTraceHeader}
+}
+
+// parseXRayTraceHeader parses an X-Amzn-Trace-Id header value
+func parseXRayTraceHeader(value string) (trace.SpanContext, bool) {
+	var cfg trace.SpanContextConfig
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Root":
+			version, rest, _ := strings.Cut(val, "-")
+			epoch, random, _ := strings.Cut(rest, "-")
+			if version != "1" || len(epoch) != 8 {
+				return trace.SpanContext{}, false
+			}
+			traceID, err := trace.TraceIDFromHex(epoch + random)
+			if err != nil {
+				return trace.SpanContext{}, false
+			}
+			cfg.TraceID = traceID
+		case "Parent":
+			spanID, err := trace.SpanIDFromHex(val)
+			if err != nil {
+				return trace.SpanContext{}, false
+			}
+			cfg.SpanID = spanID
+		case "Sampled":
+			if val == "1" {
+				cfg.TraceFlags = trace.FlagsSampled
+			}
+		}
+	}
+
+	cfg.Remote = true
+	sc := trace.NewSpanContext(cfg)
+	return sc, sc.IsValid()
+}